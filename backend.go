@@ -0,0 +1,61 @@
+/* backend.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// Get will read the key at the dconf key path with dconf read, returning ErrKeyNotExists if it is not set
+func (backend DconfBackend) Get(keyPath string) (*SchemaType, error) {
+	if _, _, splitErr := SplitKeyPath(keyPath); splitErr != nil {
+		return nil, splitErr
+	}
+
+	if _, lookErr := exec.LookPath("dconf"); lookErr != nil { // Failed to look up dconf
+		return nil, ErrNoDconfInPath
+	}
+
+	output, readErr := exec.Command("dconf", "read", keyPath).Output()
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	value := strings.TrimSpace(string(output))
+
+	if value == "" { // Not set
+		return nil, ErrKeyNotExists
+	}
+
+	return NewSchemaType(value)
+}
+
+// Set will write the key at the dconf key path with dconf write
+func (backend DconfBackend) Set(keyPath string, t *SchemaType) error {
+	if _, _, splitErr := SplitKeyPath(keyPath); splitErr != nil {
+		return splitErr
+	}
+
+	if _, lookErr := exec.LookPath("dconf"); lookErr != nil { // Failed to look up dconf
+		return ErrNoDconfInPath
+	}
+
+	return exec.Command("dconf", "write", keyPath, t.rawValue()).Run()
+}
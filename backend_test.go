@@ -0,0 +1,77 @@
+/* backend_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestBackends will test that both Schema and DconfBackend are Backends, and DconfBackend's checks
+func TestBackends(t *testing.T) {
+	var backend Backend = NewTestSchema(t)
+
+	if value, getErr := backend.Get("/com/solus-project/budgie-panel/dark-theme"); getErr != nil || !value.BoolVal {
+		t.Errorf("Unexpected dark-theme: %+v (%v)", value, getErr)
+	}
+
+	backend = DconfBackend{}
+
+	if _, getErr := backend.Get("/not/a/key/"); !errors.Is(getErr, ErrInvalidKeyPath) {
+		t.Errorf("Expected ErrInvalidKeyPath, got %v instead.", getErr)
+	}
+
+	if _, lookErr := exec.LookPath("dconf"); lookErr == nil {
+		t.Skip("dconf is available, so not testing without it")
+	}
+
+	if _, getErr := backend.Get("/org/example/key"); !errors.Is(getErr, ErrNoDconfInPath) {
+		t.Errorf("Expected ErrNoDconfInPath, got %v instead.", getErr)
+	}
+
+	if setErr := backend.Set("/org/example/key", &SchemaType{Type: "bool", Val: "true"}); !errors.Is(setErr, ErrNoDconfInPath) {
+		t.Errorf("Expected ErrNoDconfInPath, got %v instead.", setErr)
+	}
+}
+
+// TestDconfBackendSet will test that DconfBackend writes the value text, so large numbers stay integers
+func TestDconfBackendSet(t *testing.T) {
+	dir := t.TempDir()
+	stub := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\n"
+
+	if writeErr := os.WriteFile(filepath.Join(dir, "dconf"), []byte(stub), 0755); writeErr != nil {
+		t.Fatalf("Failed to write the dconf stub: %s", writeErr)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	for _, raw := range []string{"2000000", "1000000.0", "uint32 4000000", "int64 9007199254740993"} {
+		value, _ := NewSchemaType(raw)
+
+		if setErr := (DconfBackend{}).Set("/org/example/big", value); setErr != nil {
+			t.Fatalf("Failed to set %s: %s", raw, setErr)
+		}
+
+		if args, _ := os.ReadFile(filepath.Join(dir, "args")); string(args) != "write /org/example/big "+raw+"\n" {
+			t.Errorf("Expected %s to be written, got %s instead.", raw, args)
+		}
+	}
+}
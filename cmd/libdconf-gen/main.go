@@ -0,0 +1,113 @@
+/* main.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+// libdconf-gen generates Go bindings for GSettings schemas, for use with go generate, like:
+//
+//	//go:generate go run github.com/JoshStrobl/libdconf/cmd/libdconf-gen -prefix com.solus-project. -o settings_gen.go schemas/
+//
+// Each argument is a gschema.xml file, a gschemas.compiled file, or a directory of gschema.xml files
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JoshStrobl/libdconf"
+)
+
+func main() {
+	if runErr := run(os.Args[1:], os.Stdout); runErr != nil {
+		fmt.Fprintln(os.Stderr, "libdconf-gen:", runErr)
+		os.Exit(1)
+	}
+}
+
+// run will generate Go bindings for the schemas loaded from the arguments, writing them to the output file or stdout
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("libdconf-gen", flag.ContinueOnError)
+	output := flags.String("o", "", "file to write to, instead of stdout")
+	pkg := flags.String("package", os.Getenv("GOPACKAGE"), "package of the generated file, defaulting to that of go generate")
+	prefix := flags.String("prefix", "", "prefix to trim from schema and enum IDs when naming types, like com.solus-project.")
+	schemas := flags.String("schemas", "", "comma separated IDs of the schemas to generate, defaulting to every schema")
+
+	if parseErr := flags.Parse(args); parseErr != nil {
+		return parseErr
+	}
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no schema files or directories provided")
+	}
+
+	set := libdconf.NewGSchemaSet()
+
+	for _, arg := range flags.Args() {
+		argSet, loadErr := load(arg)
+
+		if loadErr != nil {
+			return fmt.Errorf("%s: %w", arg, loadErr)
+		}
+
+		if mergeErr := set.Merge(argSet); mergeErr != nil {
+			return fmt.Errorf("%s: %w", arg, mergeErr)
+		}
+	}
+
+	opts := libdconf.GoGenOptions{Package: *pkg, TrimPrefix: *prefix}
+
+	if *schemas != "" {
+		opts.Schemas = strings.Split(*schemas, ",")
+	}
+
+	source, genErr := libdconf.GenerateGo(set, opts)
+
+	if genErr != nil {
+		return genErr
+	}
+
+	if *output == "" {
+		_, writeErr := stdout.Write(source)
+		return writeErr
+	}
+
+	return os.WriteFile(*output, source, 0644)
+}
+
+// load will load the schemas of the gschema.xml file, gschemas.compiled file or directory
+func load(path string) (*libdconf.GSchemaSet, error) {
+	if info, statErr := os.Stat(path); statErr != nil {
+		return nil, statErr
+	} else if info.IsDir() {
+		return libdconf.LoadGSchemaDir(path)
+	}
+
+	content, readErr := os.ReadFile(path)
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	if filepath.Base(path) == "gschemas.compiled" {
+		return libdconf.ParseGSchemasCompiled(content)
+	}
+
+	return libdconf.ParseGSchemaXML(content)
+}
@@ -0,0 +1,73 @@
+/* main_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRun will test generating bindings from a directory, a gschema.xml file and a gschemas.compiled file
+func TestRun(t *testing.T) {
+	var stdout bytes.Buffer
+
+	if runErr := run([]string{"-package", "budgie", "-schemas", "com.solus-project.budgie-panel", "../../examples/schemas"}, &stdout); runErr != nil {
+		t.Fatalf("Failed to run: %s", runErr)
+	}
+
+	if code := stdout.String(); !strings.Contains(code, "package budgie\n") || !strings.Contains(code, "func NewComSolusProjectBudgiePanel(") {
+		t.Errorf("Unexpected code:\n%s", code)
+	}
+
+	output := filepath.Join(t.TempDir(), "gen.go")
+	args := []string{"-package", "example", "-prefix", "org.", "-o", output, "../../examples/gsettings/org.example.gschema.xml"}
+
+	if runErr := run(args, &stdout); runErr != nil {
+		t.Fatalf("Failed to run: %s", runErr)
+	}
+
+	if code, _ := os.ReadFile(output); !bytes.Contains(code, []byte("func NewExample(")) {
+		t.Errorf("Unexpected code:\n%s", code)
+	}
+
+	stdout.Reset()
+
+	if runErr := run([]string{"-package", "installed", "../../examples/image/usr/share/glib-2.0/schemas/gschemas.compiled"}, &stdout); runErr != nil || !strings.Contains(stdout.String(), "type ComSolusProjectTray struct") {
+		t.Errorf("Failed to generate from a compiled file: %v", runErr)
+	}
+}
+
+// TestRunInvalid will test that missing and conflicting schemas are reported
+func TestRunInvalid(t *testing.T) {
+	var stdout bytes.Buffer
+
+	if runErr := run([]string{}, &stdout); runErr == nil {
+		t.Error("Expected an error with no arguments")
+	}
+
+	if runErr := run([]string{"does-not-exist.gschema.xml"}, &stdout); runErr == nil {
+		t.Error("Expected an error with a missing file")
+	}
+
+	if runErr := run([]string{"../../examples/schemas", "../../examples/schemas"}, &stdout); runErr == nil {
+		t.Error("Expected an error loading the same schemas twice")
+	}
+}
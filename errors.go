@@ -24,12 +24,54 @@ import (
 )
 
 var (
+	// ErrGSchemaDuplicate is an error we return when a GSettings schema, enum or flags ID is declared more than once
+	ErrGSchemaDuplicate = errors.New("duplicate gsettings schema id")
+
+	// ErrGSchemaInvalid is an error we return when a gschema.xml file does not declare a valid GSettings schema
+	ErrGSchemaInvalid = errors.New("invalid gsettings schema")
+
+	// ErrGSchemaNotFound is an error we return when a GSettings schema we need is not in a GSchemaSet
+	ErrGSchemaNotFound = errors.New("gsettings schema not found")
+
+	// ErrGVDBInvalid is an error we return when a GVDB file, like gschemas.compiled, or a value within it is malformed
+	ErrGVDBInvalid = errors.New("invalid gvdb file")
+
+	// ErrGoGenConflict is an error we return when GenerateGo would generate the same Go identifier more than once
+	ErrGoGenConflict = errors.New("generated go identifiers conflict")
+
+	// ErrInvalidDuplicatePolicy is an error we return when ParseOptions has a Duplicates policy which is not one of our DuplicatePolicy constants
+	ErrInvalidDuplicatePolicy = errors.New("invalid duplicate policy")
+
+	// ErrInvalidKeyName is an error we return when a key name is not one dconf would accept
+	ErrInvalidKeyName = errors.New("invalid key name")
+
+	// ErrInvalidKeyPath is an error we return when a dconf key path is malformed, like /a//b or /a/dir/
+	ErrInvalidKeyPath = errors.New("invalid key path")
+
+	// ErrInvalidSectionName is an error we return when a section name is not one dconf would accept
+	ErrInvalidSectionName = errors.New("invalid section name")
+
 	// ErrKeyAlreadyExists is an error we return when we already have a key in a schema key-value store. Mostly useful for validating during section adding.
 	ErrKeyAlreadyExists = errors.New("key already exists in schemakv")
 
 	// ErrKeyNotExists is an error we return when we do not have a key in a schema
 	ErrKeyNotExists = errors.New("key does not exist")
 
+	// ErrKeyPathOutsideSchema is an error we return when a dconf key path is not under the Path of a Schema
+	ErrKeyPathOutsideSchema = errors.New("key path is outside of the schema path")
+
+	// ErrLineTooLong is an error we return when a line exceeds the MaxLineLength of our ParseOptions
+	ErrLineTooLong = errors.New("line exceeds maximum length")
+
+	// ErrMigrationLevelExists is an error we return when registering a migration for a level that already has one
+	ErrMigrationLevelExists = errors.New("migration already registered for level")
+
+	// ErrMigrationLevelInvalid is an error we return when a migration level is not a positive integer
+	ErrMigrationLevelInvalid = errors.New("migration level must be a positive integer")
+
+	// ErrMigrationStepInvalid is an error we return when a migration step is missing what it needs or has an unknown action
+	ErrMigrationStepInvalid = errors.New("invalid migration step")
+
 	// ErrModCannotDoReplace is an error we return when we cannot do a replacement of a value
 	ErrModCannotDoReplace = errors.New("cannot perform replace modification, schematype is not of ArrayAsString or string")
 
@@ -42,9 +84,48 @@ var (
 	// ErrNoDconfInPath is an error we return if we could not find dconf in the path during a dconf operation
 	ErrNoDconfInPath error = errors.New("no dconf found in path")
 
+	// ErrNothingToRedo is an error we return when attempting to redo in a Transaction with nothing undone
+	ErrNothingToRedo = errors.New("nothing to redo")
+
+	// ErrNothingToUndo is an error we return when attempting to undo in a Transaction with no changes
+	ErrNothingToUndo = errors.New("nothing to undo")
+
+	// ErrParseInvalidValue is an error we return (or warn about) when a value could not be parsed into a SchemaType
+	ErrParseInvalidValue = errors.New("invalid value")
+
+	// ErrParseKeyOutsideSection is an error we return (or warn about) when a key comes before any section
+	ErrParseKeyOutsideSection = errors.New("key is not in a section")
+
+	// ErrParseNotKeyValue is an error we return (or warn about) when a line is not a section, comment or key=value
+	ErrParseNotKeyValue = errors.New("line is not a section, comment or key=value")
+
+	// ErrPlanStale is an error we return when applying an ImportPlan after the live dconf state or the Schema changed
+	ErrPlanStale = errors.New("import plan is stale, live state or schema changed since planning")
+
+	// ErrQuerySyntax is an error we return when a Query could not be parsed
+	ErrQuerySyntax = errors.New("invalid query")
+
 	// ErrSectionDoesNotExist is an error we return if a section requested does not exist
 	ErrSectionDoesNotExist = errors.New("section does not exist")
 
 	// ErrSectionExists is an error we return if a section exists
 	ErrSectionExists = errors.New("section exists")
+
+	// ErrSkipDir is returned by a WalkFunc to skip the sections under the section it was called for. Walk itself never returns it
+	ErrSkipDir = errors.New("skip this directory")
+
+	// ErrTOMLSyntax is an error we return when a TOML file (like a migration) could not be parsed
+	ErrTOMLSyntax = errors.New("invalid toml")
+
+	// ErrTooManySections is an error we return when content has more sections than the MaxSections of our ParseOptions
+	ErrTooManySections = errors.New("too many sections")
+
+	// ErrTransactionClosed is an error we return when using a Transaction which has already been committed or rolled back
+	ErrTransactionClosed = errors.New("transaction is already committed or rolled back")
+
+	// ErrTransactionConflict is an error we return when committing a Transaction after its Schema was changed outside of it
+	ErrTransactionConflict = errors.New("schema changed outside of the transaction")
+
+	// ErrValueTooLarge is an error we return when a value exceeds the MaxValueSize of our ParseOptions
+	ErrValueTooLarge = errors.New("value exceeds maximum size")
 )
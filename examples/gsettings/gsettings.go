@@ -0,0 +1,21 @@
+/* gsettings.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gsettings is an example of the Go bindings libdconf-gen generates, for our example schemas
+package gsettings
+
+//go:generate go run ../../cmd/libdconf-gen -prefix com.solus-project. -o gsettings_gen.go ../schemas .
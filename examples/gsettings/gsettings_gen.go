@@ -0,0 +1,844 @@
+// Code generated by libdconf-gen from GSettings schemas. DO NOT EDIT.
+
+package gsettings
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/JoshStrobl/libdconf"
+)
+
+// BudgiePanelAlignment is a nick of the com.solus-project.budgie-panel.Alignment enum
+type BudgiePanelAlignment string
+
+const (
+	BudgiePanelAlignmentStart  BudgiePanelAlignment = "start"  // 0
+	BudgiePanelAlignmentCenter BudgiePanelAlignment = "center" // 1
+	BudgiePanelAlignmentEnd    BudgiePanelAlignment = "end"    // 2
+)
+
+// BudgiePanelAutohidePolicy is a nick of the com.solus-project.budgie-panel.AutohidePolicy enum
+type BudgiePanelAutohidePolicy string
+
+const (
+	BudgiePanelAutohidePolicyNone        BudgiePanelAutohidePolicy = "none"        // 0
+	BudgiePanelAutohidePolicyAutomatic   BudgiePanelAutohidePolicy = "automatic"   // 1
+	BudgiePanelAutohidePolicyIntelligent BudgiePanelAutohidePolicy = "intelligent" // 2
+)
+
+// BudgiePanelPanelPosition is a nick of the com.solus-project.budgie-panel.PanelPosition enum
+type BudgiePanelPanelPosition string
+
+const (
+	BudgiePanelPanelPositionNone   BudgiePanelPanelPosition = "none"   // 1
+	BudgiePanelPanelPositionBottom BudgiePanelPanelPosition = "bottom" // 2
+	BudgiePanelPanelPositionTop    BudgiePanelPanelPosition = "top"    // 4
+	BudgiePanelPanelPositionLeft   BudgiePanelPanelPosition = "left"   // 8
+	BudgiePanelPanelPositionRight  BudgiePanelPanelPosition = "right"  // 16
+)
+
+// BudgiePanelPanelTransparency is a nick of the com.solus-project.budgie-panel.PanelTransparency enum
+type BudgiePanelPanelTransparency string
+
+const (
+	BudgiePanelPanelTransparencyNone    BudgiePanelPanelTransparency = "none"    // 1
+	BudgiePanelPanelTransparencyDynamic BudgiePanelPanelTransparency = "dynamic" // 2
+	BudgiePanelPanelTransparencyAlways  BudgiePanelPanelTransparency = "always"  // 4
+)
+
+// OrgExampleFeatures is a nick of the org.example.Features flags
+type OrgExampleFeatures string
+
+const (
+	OrgExampleFeaturesSound OrgExampleFeatures = "sound" // 1
+	OrgExampleFeaturesVideo OrgExampleFeatures = "video" // 2
+)
+
+// OrgExampleMode is a nick of the org.example.Mode enum
+type OrgExampleMode string
+
+const (
+	OrgExampleModeOff OrgExampleMode = "off" // 0
+	OrgExampleModeOn  OrgExampleMode = "on"  // 1
+)
+
+// BudgieMenu is the relocatable com.solus-project.budgie-menu GSettings schema
+type BudgieMenu struct {
+	backend libdconf.Backend
+	path    string
+}
+
+// NewBudgieMenu will return the com.solus-project.budgie-menu schema at the path, reading and writing through the backend
+func NewBudgieMenu(backend libdconf.Backend, path string) *BudgieMenu {
+	return &BudgieMenu{backend: backend, path: strings.TrimSuffix(path, "/") + "/"}
+}
+
+// Path will return the path of the schema
+func (settings *BudgieMenu) Path() string {
+	return settings.path
+}
+
+// MenuIcon will return menu-icon (Menu icon), or its default of 'view-grid-symbolic' if unset
+func (settings *BudgieMenu) MenuIcon() string {
+	if text, set := gsettingsGet(settings.backend, settings.path+"menu-icon"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return value
+		}
+	}
+
+	return "view-grid-symbolic"
+}
+
+// SetMenuIcon will set menu-icon
+func (settings *BudgieMenu) SetMenuIcon(value string) error {
+	return gsettingsSet(settings.backend, settings.path+"menu-icon", libdconf.QuoteString(value))
+}
+
+// MenuLabel will return menu-label (Menu label), or its default of 'Menu' if unset
+func (settings *BudgieMenu) MenuLabel() string {
+	if text, set := gsettingsGet(settings.backend, settings.path+"menu-label"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return value
+		}
+	}
+
+	return "Menu"
+}
+
+// SetMenuLabel will set menu-label
+func (settings *BudgieMenu) SetMenuLabel(value string) error {
+	return gsettingsSet(settings.backend, settings.path+"menu-label", libdconf.QuoteString(value))
+}
+
+// BudgiePanel is the com.solus-project.budgie-panel GSettings schema, at /com/solus-project/budgie-panel/
+type BudgiePanel struct {
+	backend libdconf.Backend
+	path    string
+}
+
+// NewBudgiePanel will return the com.solus-project.budgie-panel schema, reading and writing through the backend
+func NewBudgiePanel(backend libdconf.Backend) *BudgiePanel {
+	return &BudgiePanel{backend: backend, path: "/com/solus-project/budgie-panel/"}
+}
+
+// Path will return the path of the schema
+func (settings *BudgiePanel) Path() string {
+	return settings.path
+}
+
+// DarkTheme will return dark-theme (Dark theme), or its default of true if unset
+func (settings *BudgiePanel) DarkTheme() bool {
+	if text, set := gsettingsGet(settings.backend, settings.path+"dark-theme"); set {
+		if value, parseErr := strconv.ParseBool(text); parseErr == nil {
+			return value
+		}
+	}
+
+	return true
+}
+
+// SetDarkTheme will set dark-theme
+func (settings *BudgiePanel) SetDarkTheme(value bool) error {
+	return gsettingsSet(settings.backend, settings.path+"dark-theme", strconv.FormatBool(value))
+}
+
+// Layout will return layout (Panel layout), or its default of 'default' if unset
+func (settings *BudgiePanel) Layout() string {
+	if text, set := gsettingsGet(settings.backend, settings.path+"layout"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return value
+		}
+	}
+
+	return "default"
+}
+
+// SetLayout will set layout
+func (settings *BudgiePanel) SetLayout(value string) error {
+	return gsettingsSet(settings.backend, settings.path+"layout", libdconf.QuoteString(value))
+}
+
+// MigrationLevel will return migration-level (Migration level), or its default of 0 if unset
+func (settings *BudgiePanel) MigrationLevel() uint32 {
+	if text, set := gsettingsGet(settings.backend, settings.path+"migration-level"); set {
+		if value, parseErr := strconv.ParseUint(gsettingsNumber(text), 0, 32); parseErr == nil {
+			return uint32(value)
+		}
+	}
+
+	return 0
+}
+
+// SetMigrationLevel will set migration-level
+func (settings *BudgiePanel) SetMigrationLevel(value uint32) error {
+	return gsettingsSet(settings.backend, settings.path+"migration-level", "uint32 "+strconv.FormatUint(uint64(value), 10))
+}
+
+// Panels will return panels (Panels), or its default of [] if unset
+func (settings *BudgiePanel) Panels() []string {
+	if text, set := gsettingsGet(settings.backend, settings.path+"panels"); set {
+		if value, valid := gsettingsStrings(text); valid {
+			return value
+		}
+	}
+
+	return []string{}
+}
+
+// SetPanels will set panels
+func (settings *BudgiePanel) SetPanels(value []string) error {
+	return gsettingsSet(settings.backend, settings.path+"panels", gsettingsQuoteStrings(value))
+}
+
+// BudgiePanelApplet is the relocatable com.solus-project.budgie-panel.applet GSettings schema
+type BudgiePanelApplet struct {
+	backend libdconf.Backend
+	path    string
+}
+
+// NewBudgiePanelApplet will return the com.solus-project.budgie-panel.applet schema at the path, reading and writing through the backend
+func NewBudgiePanelApplet(backend libdconf.Backend, path string) *BudgiePanelApplet {
+	return &BudgiePanelApplet{backend: backend, path: strings.TrimSuffix(path, "/") + "/"}
+}
+
+// Path will return the path of the schema
+func (settings *BudgiePanelApplet) Path() string {
+	return settings.path
+}
+
+// Alignment will return alignment (Alignment), or its default of 'start' if unset
+func (settings *BudgiePanelApplet) Alignment() BudgiePanelAlignment {
+	if text, set := gsettingsGet(settings.backend, settings.path+"alignment"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return BudgiePanelAlignment(value)
+		}
+	}
+
+	return BudgiePanelAlignmentStart
+}
+
+// SetAlignment will set alignment
+func (settings *BudgiePanelApplet) SetAlignment(value BudgiePanelAlignment) error {
+	return gsettingsSet(settings.backend, settings.path+"alignment", libdconf.QuoteString(string(value)))
+}
+
+// Name will return name (Plugin name), or its default of ” if unset
+func (settings *BudgiePanelApplet) Name() string {
+	if text, set := gsettingsGet(settings.backend, settings.path+"name"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// SetName will set name
+func (settings *BudgiePanelApplet) SetName(value string) error {
+	return gsettingsSet(settings.backend, settings.path+"name", libdconf.QuoteString(value))
+}
+
+// Position will return position (Position within its alignment), or its default of 0 if unset
+func (settings *BudgiePanelApplet) Position() int32 {
+	if text, set := gsettingsGet(settings.backend, settings.path+"position"); set {
+		if value, parseErr := strconv.ParseInt(gsettingsNumber(text), 0, 32); parseErr == nil {
+			return int32(value)
+		}
+	}
+
+	return 0
+}
+
+// SetPosition will set position
+func (settings *BudgiePanelApplet) SetPosition(value int32) error {
+	return gsettingsSet(settings.backend, settings.path+"position", strconv.FormatInt(int64(value), 10))
+}
+
+// BudgiePanelPanel is the relocatable com.solus-project.budgie-panel.panel GSettings schema
+type BudgiePanelPanel struct {
+	backend libdconf.Backend
+	path    string
+}
+
+// NewBudgiePanelPanel will return the com.solus-project.budgie-panel.panel schema at the path, reading and writing through the backend
+func NewBudgiePanelPanel(backend libdconf.Backend, path string) *BudgiePanelPanel {
+	return &BudgiePanelPanel{backend: backend, path: strings.TrimSuffix(path, "/") + "/"}
+}
+
+// Path will return the path of the schema
+func (settings *BudgiePanelPanel) Path() string {
+	return settings.path
+}
+
+// Applets will return applets (Applets), or its default of [] if unset
+func (settings *BudgiePanelPanel) Applets() []string {
+	if text, set := gsettingsGet(settings.backend, settings.path+"applets"); set {
+		if value, valid := gsettingsStrings(text); valid {
+			return value
+		}
+	}
+
+	return []string{}
+}
+
+// SetApplets will set applets
+func (settings *BudgiePanelPanel) SetApplets(value []string) error {
+	return gsettingsSet(settings.backend, settings.path+"applets", gsettingsQuoteStrings(value))
+}
+
+// Autohide will return autohide (Autohide policy), or its default of 'none' if unset
+func (settings *BudgiePanelPanel) Autohide() BudgiePanelAutohidePolicy {
+	if text, set := gsettingsGet(settings.backend, settings.path+"autohide"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return BudgiePanelAutohidePolicy(value)
+		}
+	}
+
+	return BudgiePanelAutohidePolicyNone
+}
+
+// SetAutohide will set autohide
+func (settings *BudgiePanelPanel) SetAutohide(value BudgiePanelAutohidePolicy) error {
+	return gsettingsSet(settings.backend, settings.path+"autohide", libdconf.QuoteString(string(value)))
+}
+
+// DockMode will return dock-mode (Dock mode), or its default of false if unset
+func (settings *BudgiePanelPanel) DockMode() bool {
+	if text, set := gsettingsGet(settings.backend, settings.path+"dock-mode"); set {
+		if value, parseErr := strconv.ParseBool(text); parseErr == nil {
+			return value
+		}
+	}
+
+	return false
+}
+
+// SetDockMode will set dock-mode
+func (settings *BudgiePanelPanel) SetDockMode(value bool) error {
+	return gsettingsSet(settings.backend, settings.path+"dock-mode", strconv.FormatBool(value))
+}
+
+// EnableShadow will return enable-shadow (Shadow), or its default of true if unset
+func (settings *BudgiePanelPanel) EnableShadow() bool {
+	if text, set := gsettingsGet(settings.backend, settings.path+"enable-shadow"); set {
+		if value, parseErr := strconv.ParseBool(text); parseErr == nil {
+			return value
+		}
+	}
+
+	return true
+}
+
+// SetEnableShadow will set enable-shadow
+func (settings *BudgiePanelPanel) SetEnableShadow(value bool) error {
+	return gsettingsSet(settings.backend, settings.path+"enable-shadow", strconv.FormatBool(value))
+}
+
+// Location will return location (Panel location), or its default of 'bottom' if unset
+func (settings *BudgiePanelPanel) Location() BudgiePanelPanelPosition {
+	if text, set := gsettingsGet(settings.backend, settings.path+"location"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return BudgiePanelPanelPosition(value)
+		}
+	}
+
+	return BudgiePanelPanelPositionBottom
+}
+
+// SetLocation will set location
+func (settings *BudgiePanelPanel) SetLocation(value BudgiePanelPanelPosition) error {
+	return gsettingsSet(settings.backend, settings.path+"location", libdconf.QuoteString(string(value)))
+}
+
+// Size will return size (Panel size), or its default of 36 if unset
+func (settings *BudgiePanelPanel) Size() int32 {
+	if text, set := gsettingsGet(settings.backend, settings.path+"size"); set {
+		if value, parseErr := strconv.ParseInt(gsettingsNumber(text), 0, 32); parseErr == nil {
+			return int32(value)
+		}
+	}
+
+	return 36
+}
+
+// SetSize will set size
+func (settings *BudgiePanelPanel) SetSize(value int32) error {
+	return gsettingsSet(settings.backend, settings.path+"size", strconv.FormatInt(int64(value), 10))
+}
+
+// ThemeRegions will return theme-regions (Theme regions), or its default of true if unset
+func (settings *BudgiePanelPanel) ThemeRegions() bool {
+	if text, set := gsettingsGet(settings.backend, settings.path+"theme-regions"); set {
+		if value, parseErr := strconv.ParseBool(text); parseErr == nil {
+			return value
+		}
+	}
+
+	return true
+}
+
+// SetThemeRegions will set theme-regions
+func (settings *BudgiePanelPanel) SetThemeRegions(value bool) error {
+	return gsettingsSet(settings.backend, settings.path+"theme-regions", strconv.FormatBool(value))
+}
+
+// Transparency will return transparency (Transparency), or its default of 'none' if unset
+func (settings *BudgiePanelPanel) Transparency() BudgiePanelPanelTransparency {
+	if text, set := gsettingsGet(settings.backend, settings.path+"transparency"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return BudgiePanelPanelTransparency(value)
+		}
+	}
+
+	return BudgiePanelPanelTransparencyNone
+}
+
+// SetTransparency will set transparency
+func (settings *BudgiePanelPanel) SetTransparency(value BudgiePanelPanelTransparency) error {
+	return gsettingsSet(settings.backend, settings.path+"transparency", libdconf.QuoteString(string(value)))
+}
+
+// IconTasklist is the relocatable com.solus-project.icon-tasklist GSettings schema
+type IconTasklist struct {
+	backend libdconf.Backend
+	path    string
+}
+
+// NewIconTasklist will return the com.solus-project.icon-tasklist schema at the path, reading and writing through the backend
+func NewIconTasklist(backend libdconf.Backend, path string) *IconTasklist {
+	return &IconTasklist{backend: backend, path: strings.TrimSuffix(path, "/") + "/"}
+}
+
+// Path will return the path of the schema
+func (settings *IconTasklist) Path() string {
+	return settings.path
+}
+
+// OnlyPinned will return only-pinned (Only show pinned launchers), or its default of false if unset
+func (settings *IconTasklist) OnlyPinned() bool {
+	if text, set := gsettingsGet(settings.backend, settings.path+"only-pinned"); set {
+		if value, parseErr := strconv.ParseBool(text); parseErr == nil {
+			return value
+		}
+	}
+
+	return false
+}
+
+// SetOnlyPinned will set only-pinned
+func (settings *IconTasklist) SetOnlyPinned(value bool) error {
+	return gsettingsSet(settings.backend, settings.path+"only-pinned", strconv.FormatBool(value))
+}
+
+// PinnedLaunchers will return pinned-launchers (Pinned launchers), or its default of ['firefox.desktop', 'org.gnome.Nautilus.desktop'] if unset
+func (settings *IconTasklist) PinnedLaunchers() []string {
+	if text, set := gsettingsGet(settings.backend, settings.path+"pinned-launchers"); set {
+		if value, valid := gsettingsStrings(text); valid {
+			return value
+		}
+	}
+
+	return []string{"firefox.desktop", "org.gnome.Nautilus.desktop"}
+}
+
+// SetPinnedLaunchers will set pinned-launchers
+func (settings *IconTasklist) SetPinnedLaunchers(value []string) error {
+	return gsettingsSet(settings.backend, settings.path+"pinned-launchers", gsettingsQuoteStrings(value))
+}
+
+// RestrictToWorkspace will return restrict-to-workspace (Restrict to the current workspace), or its default of false if unset
+func (settings *IconTasklist) RestrictToWorkspace() bool {
+	if text, set := gsettingsGet(settings.backend, settings.path+"restrict-to-workspace"); set {
+		if value, parseErr := strconv.ParseBool(text); parseErr == nil {
+			return value
+		}
+	}
+
+	return false
+}
+
+// SetRestrictToWorkspace will set restrict-to-workspace
+func (settings *IconTasklist) SetRestrictToWorkspace(value bool) error {
+	return gsettingsSet(settings.backend, settings.path+"restrict-to-workspace", strconv.FormatBool(value))
+}
+
+// Tray is the relocatable com.solus-project.tray GSettings schema
+type Tray struct {
+	backend libdconf.Backend
+	path    string
+}
+
+// NewTray will return the com.solus-project.tray schema at the path, reading and writing through the backend
+func NewTray(backend libdconf.Backend, path string) *Tray {
+	return &Tray{backend: backend, path: strings.TrimSuffix(path, "/") + "/"}
+}
+
+// Path will return the path of the schema
+func (settings *Tray) Path() string {
+	return settings.path
+}
+
+// Spacing will return spacing (Spacing between icons), or its default of 2 if unset
+func (settings *Tray) Spacing() int32 {
+	if text, set := gsettingsGet(settings.backend, settings.path+"spacing"); set {
+		if value, parseErr := strconv.ParseInt(gsettingsNumber(text), 0, 32); parseErr == nil {
+			return int32(value)
+		}
+	}
+
+	return 2
+}
+
+// SetSpacing will set spacing
+func (settings *Tray) SetSpacing(value int32) error {
+	return gsettingsSet(settings.backend, settings.path+"spacing", strconv.FormatInt(int64(value), 10))
+}
+
+// OrgExample is the org.example GSettings schema, at /org/example/
+type OrgExample struct {
+	backend libdconf.Backend
+	path    string
+}
+
+// NewOrgExample will return the org.example schema, reading and writing through the backend
+func NewOrgExample(backend libdconf.Backend) *OrgExample {
+	return &OrgExample{backend: backend, path: "/org/example/"}
+}
+
+// Path will return the path of the schema
+func (settings *OrgExample) Path() string {
+	return settings.path
+}
+
+// Window will return the window child, the org.example.window schema
+func (settings *OrgExample) Window() *OrgExampleWindow {
+	return &OrgExampleWindow{backend: settings.backend, path: settings.path + "window/"}
+}
+
+// Color will return color (Color), or its default of 'blue' if unset
+func (settings *OrgExample) Color() string {
+	if text, set := gsettingsGet(settings.backend, settings.path+"color"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return value
+		}
+	}
+
+	return "blue"
+}
+
+// SetColor will set color
+func (settings *OrgExample) SetColor(value string) error {
+	return gsettingsSet(settings.backend, settings.path+"color", libdconf.QuoteString(value))
+}
+
+// Features will return features, or its default of ['sound'] if unset
+func (settings *OrgExample) Features() []OrgExampleFeatures {
+	if text, set := gsettingsGet(settings.backend, settings.path+"features"); set {
+		if nicks, valid := gsettingsStrings(text); valid {
+			value := make([]OrgExampleFeatures, 0, len(nicks))
+
+			for _, nick := range nicks {
+				value = append(value, OrgExampleFeatures(nick))
+			}
+
+			return value
+		}
+	}
+
+	return []OrgExampleFeatures{OrgExampleFeaturesSound}
+}
+
+// SetFeatures will set features
+func (settings *OrgExample) SetFeatures(value []OrgExampleFeatures) error {
+	nicks := make([]string, 0, len(value))
+
+	for _, nick := range value {
+		nicks = append(nicks, string(nick))
+	}
+
+	return gsettingsSet(settings.backend, settings.path+"features", gsettingsQuoteStrings(nicks))
+}
+
+// Mode will return mode, or its default of 'off' if unset
+func (settings *OrgExample) Mode() OrgExampleMode {
+	if text, set := gsettingsGet(settings.backend, settings.path+"mode"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return OrgExampleMode(value)
+		}
+	}
+
+	return OrgExampleModeOff
+}
+
+// SetMode will set mode
+func (settings *OrgExample) SetMode(value OrgExampleMode) error {
+	return gsettingsSet(settings.backend, settings.path+"mode", libdconf.QuoteString(string(value)))
+}
+
+// Volume will return volume, or its default of 0.5 if unset
+func (settings *OrgExample) Volume() float64 {
+	if text, set := gsettingsGet(settings.backend, settings.path+"volume"); set {
+		if value, parseErr := strconv.ParseFloat(gsettingsNumber(text), 64); parseErr == nil {
+			return value
+		}
+	}
+
+	return 0.5
+}
+
+// SetVolume will set volume
+func (settings *OrgExample) SetVolume(value float64) error {
+	return gsettingsSet(settings.backend, settings.path+"volume", gsettingsDouble(value))
+}
+
+// OrgExampleBase is the relocatable org.example.base GSettings schema
+type OrgExampleBase struct {
+	backend libdconf.Backend
+	path    string
+}
+
+// NewOrgExampleBase will return the org.example.base schema at the path, reading and writing through the backend
+func NewOrgExampleBase(backend libdconf.Backend, path string) *OrgExampleBase {
+	return &OrgExampleBase{backend: backend, path: strings.TrimSuffix(path, "/") + "/"}
+}
+
+// Path will return the path of the schema
+func (settings *OrgExampleBase) Path() string {
+	return settings.path
+}
+
+// Color will return color (Color), or its default of 'red' if unset
+func (settings *OrgExampleBase) Color() string {
+	if text, set := gsettingsGet(settings.backend, settings.path+"color"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return value
+		}
+	}
+
+	return "red"
+}
+
+// SetColor will set color
+func (settings *OrgExampleBase) SetColor(value string) error {
+	return gsettingsSet(settings.backend, settings.path+"color", libdconf.QuoteString(value))
+}
+
+// Mode will return mode, or its default of 'off' if unset
+func (settings *OrgExampleBase) Mode() OrgExampleMode {
+	if text, set := gsettingsGet(settings.backend, settings.path+"mode"); set {
+		if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {
+			return OrgExampleMode(value)
+		}
+	}
+
+	return OrgExampleModeOff
+}
+
+// SetMode will set mode
+func (settings *OrgExampleBase) SetMode(value OrgExampleMode) error {
+	return gsettingsSet(settings.backend, settings.path+"mode", libdconf.QuoteString(string(value)))
+}
+
+// OrgExampleNumbers is the org.example.numbers GSettings schema, at /org/example/numbers/
+type OrgExampleNumbers struct {
+	backend libdconf.Backend
+	path    string
+}
+
+// NewOrgExampleNumbers will return the org.example.numbers schema, reading and writing through the backend
+func NewOrgExampleNumbers(backend libdconf.Backend) *OrgExampleNumbers {
+	return &OrgExampleNumbers{backend: backend, path: "/org/example/numbers/"}
+}
+
+// Path will return the path of the schema
+func (settings *OrgExampleNumbers) Path() string {
+	return settings.path
+}
+
+// Count will return count (A signed 32-bit count), or its default of 0 if unset
+func (settings *OrgExampleNumbers) Count() int32 {
+	if text, set := gsettingsGet(settings.backend, settings.path+"count"); set {
+		if value, parseErr := strconv.ParseInt(gsettingsNumber(text), 0, 32); parseErr == nil {
+			return int32(value)
+		}
+	}
+
+	return 0
+}
+
+// SetCount will set count
+func (settings *OrgExampleNumbers) SetCount(value int32) error {
+	return gsettingsSet(settings.backend, settings.path+"count", strconv.FormatInt(int64(value), 10))
+}
+
+// Offset will return offset (A signed 64-bit offset), or its default of 0 if unset
+func (settings *OrgExampleNumbers) Offset() int64 {
+	if text, set := gsettingsGet(settings.backend, settings.path+"offset"); set {
+		if value, parseErr := strconv.ParseInt(gsettingsNumber(text), 0, 64); parseErr == nil {
+			return int64(value)
+		}
+	}
+
+	return 0
+}
+
+// SetOffset will set offset
+func (settings *OrgExampleNumbers) SetOffset(value int64) error {
+	return gsettingsSet(settings.backend, settings.path+"offset", "int64 "+strconv.FormatInt(int64(value), 10))
+}
+
+// Ratio will return ratio (A double ratio), or its default of 0.0 if unset
+func (settings *OrgExampleNumbers) Ratio() float64 {
+	if text, set := gsettingsGet(settings.backend, settings.path+"ratio"); set {
+		if value, parseErr := strconv.ParseFloat(gsettingsNumber(text), 64); parseErr == nil {
+			return value
+		}
+	}
+
+	return 0.0
+}
+
+// SetRatio will set ratio
+func (settings *OrgExampleNumbers) SetRatio(value float64) error {
+	return gsettingsSet(settings.backend, settings.path+"ratio", gsettingsDouble(value))
+}
+
+// Total will return total (An unsigned 32-bit total), or its default of 0 if unset
+func (settings *OrgExampleNumbers) Total() uint32 {
+	if text, set := gsettingsGet(settings.backend, settings.path+"total"); set {
+		if value, parseErr := strconv.ParseUint(gsettingsNumber(text), 0, 32); parseErr == nil {
+			return uint32(value)
+		}
+	}
+
+	return 0
+}
+
+// SetTotal will set total
+func (settings *OrgExampleNumbers) SetTotal(value uint32) error {
+	return gsettingsSet(settings.backend, settings.path+"total", "uint32 "+strconv.FormatUint(uint64(value), 10))
+}
+
+// OrgExampleWindow is the relocatable org.example.window GSettings schema
+type OrgExampleWindow struct {
+	backend libdconf.Backend
+	path    string
+}
+
+// NewOrgExampleWindow will return the org.example.window schema at the path, reading and writing through the backend
+func NewOrgExampleWindow(backend libdconf.Backend, path string) *OrgExampleWindow {
+	return &OrgExampleWindow{backend: backend, path: strings.TrimSuffix(path, "/") + "/"}
+}
+
+// Path will return the path of the schema
+func (settings *OrgExampleWindow) Path() string {
+	return settings.path
+}
+
+// Size will return size, or its default of (640, 480) if unset
+func (settings *OrgExampleWindow) Size() string {
+	if text, set := gsettingsGet(settings.backend, settings.path+"size"); set {
+		return text
+	}
+
+	return "(640, 480)"
+}
+
+// SetSize will set size
+func (settings *OrgExampleWindow) SetSize(value string) error {
+	return gsettingsSet(settings.backend, settings.path+"size", value)
+}
+
+// gsettingsGet will return the text of the key at the path, and whether it is set
+func gsettingsGet(backend libdconf.Backend, keyPath string) (string, bool) {
+	value, getErr := backend.Get(keyPath)
+
+	if getErr != nil || value == nil {
+		return "", false
+	}
+
+	text := value.Val
+
+	if parsed, parseErr := libdconf.NewSchemaType(text); parseErr != nil || !parsed.Matches(value) { // Val is out of date, so format the value
+		text = value.String()
+	}
+
+	return strings.TrimSpace(text), true
+}
+
+// gsettingsSet will set the key at the path to the value in the GVariant text format
+func gsettingsSet(backend libdconf.Backend, keyPath string, text string) error {
+	value, parseErr := libdconf.NewSchemaType(text)
+
+	if parseErr != nil {
+		return parseErr
+	}
+
+	return backend.Set(keyPath, value)
+}
+
+// gsettingsNumber will return the number without any type annotation, like 5 for uint32 5
+func gsettingsNumber(text string) string {
+	fields := strings.Fields(text)
+
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return fields[len(fields)-1]
+}
+
+// gsettingsDouble will format the double so it is not mistaken for an integer
+func gsettingsDouble(value float64) string {
+	text := strconv.FormatFloat(value, 'g', -1, 64)
+
+	if value == math.Trunc(value) && math.Abs(value) < 1e21 { // Integral, so avoid an exponent like 1e+06
+		text = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	if !strings.ContainsAny(text, ".eIN") {
+		text += ".0"
+	}
+
+	return text
+}
+
+// gsettingsStrings will decode the array of strings, and whether it is one
+func gsettingsStrings(text string) ([]string, bool) {
+	items, splitErr := libdconf.SplitArray(text)
+
+	if splitErr != nil {
+		return nil, false
+	}
+
+	strs := make([]string, 0, len(items))
+
+	for _, item := range items {
+		str, unquoteErr := libdconf.UnquoteString(item)
+
+		if unquoteErr != nil {
+			return nil, false
+		}
+
+		strs = append(strs, str)
+	}
+
+	return strs, true
+}
+
+// gsettingsQuoteStrings will encode the strings as an array of strings
+func gsettingsQuoteStrings(strs []string) string {
+	if len(strs) == 0 {
+		return "@as []"
+	}
+
+	quoted := make([]string, 0, len(strs))
+
+	for _, str := range strs {
+		quoted = append(quoted, libdconf.QuoteString(str))
+	}
+
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
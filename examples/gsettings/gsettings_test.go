@@ -0,0 +1,121 @@
+/* gsettings_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gsettings
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/JoshStrobl/libdconf"
+)
+
+// TestBudgiePanel will test reading and writing our example content through the generated bindings
+func TestBudgiePanel(t *testing.T) {
+	content, readErr := os.ReadFile("../com__solus-project__budgie-panel")
+
+	if readErr != nil {
+		t.Fatalf("Failed to read: %s", readErr)
+	}
+
+	schema, _ := libdconf.NewSchema("/com/solus-project/budgie-panel/", content)
+	panel := NewBudgiePanel(schema)
+
+	if !panel.DarkTheme() || panel.Layout() != "solus-fortitude" || panel.MigrationLevel() != 1 || !reflect.DeepEqual(panel.Panels(), []string{"8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c"}) {
+		t.Errorf("Unexpected panel settings: %t %s %d %v", panel.DarkTheme(), panel.Layout(), panel.MigrationLevel(), panel.Panels())
+	}
+
+	applet := NewBudgiePanelApplet(schema, panel.Path()+"applets/{8bc94562-0dae-11eb-ad1d-e0d55e200f1c}")
+
+	if applet.Name() != "Clock" || applet.Alignment() != BudgiePanelAlignmentEnd || applet.Position() != 7 {
+		t.Errorf("Unexpected applet settings: %s %s %d", applet.Name(), applet.Alignment(), applet.Position())
+	}
+
+	second := NewBudgiePanelPanel(schema, panel.Path()+"panels/{e41d503c-103d-11eb-b26a-e0d55e200f1c}/")
+
+	if second.Size() != 39 || second.Autohide() != BudgiePanelAutohidePolicyNone || !second.EnableShadow() {
+		t.Errorf("Expected set and default values, got %d %s %t", second.Size(), second.Autohide(), second.EnableShadow())
+	}
+
+	if setErr := second.SetAutohide(BudgiePanelAutohidePolicyIntelligent); setErr != nil || second.Autohide() != BudgiePanelAutohidePolicyIntelligent {
+		t.Errorf("Failed to set autohide: %v", setErr)
+	}
+
+	if setErr := panel.SetMigrationLevel(2); setErr != nil || panel.MigrationLevel() != 2 {
+		t.Errorf("Failed to set migration-level: %v", setErr)
+	}
+
+	if level, _ := schema.Get("migration-level"); level.String() != "uint32 2" {
+		t.Errorf("Expected migration-level to be written as a uint32, got %s", level)
+	}
+
+	if setErr := panel.SetPanels(nil); setErr != nil || len(panel.Panels()) != 0 {
+		t.Errorf("Failed to clear panels: %v", setErr)
+	}
+}
+
+// TestOrgExample will test flags, doubles, children and types without a Go equivalent
+func TestOrgExample(t *testing.T) {
+	schema, _ := libdconf.NewSchema("/org/example/", []byte("[/]\nfeatures=['video']\n"))
+	example := NewOrgExample(schema)
+
+	if features := example.Features(); !reflect.DeepEqual(features, []OrgExampleFeatures{OrgExampleFeaturesVideo}) {
+		t.Errorf("Unexpected features: %v", features)
+	}
+
+	if example.Color() != "blue" || example.Mode() != OrgExampleModeOff || example.Volume() != 0.5 || example.Window().Size() != "(640, 480)" {
+		t.Errorf("Unexpected defaults: %s %s %g %s", example.Color(), example.Mode(), example.Volume(), example.Window().Size())
+	}
+
+	example.SetVolume(1)
+	example.SetFeatures([]OrgExampleFeatures{OrgExampleFeaturesSound, OrgExampleFeaturesVideo})
+	example.Window().SetSize("(800, 600)")
+
+	expected := "[/]\nfeatures=['sound', 'video']\nvolume=1.0\n\n[window]\nsize=(800, 600)\n"
+
+	if schema.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, schema.String())
+	}
+}
+
+// TestOrgExampleNumbers will test that numbers of a million or more are read back as written
+func TestOrgExampleNumbers(t *testing.T) {
+	schema, _ := libdconf.NewSchema("/org/example/numbers/", []byte("[/]\ncount=1000000\nratio=1000000.0\n"))
+	numbers := NewOrgExampleNumbers(schema)
+
+	if numbers.Count() != 1000000 || numbers.Ratio() != 1000000 {
+		t.Errorf("Unexpected numbers: %d %g", numbers.Count(), numbers.Ratio())
+	}
+
+	numbers.SetCount(-2000000)
+	numbers.SetTotal(4000000000)
+	numbers.SetOffset(9007199254740993)
+	numbers.SetRatio(2000000)
+
+	if numbers.Count() != -2000000 || numbers.Total() != 4000000000 || numbers.Offset() != 9007199254740993 || numbers.Ratio() != 2000000 {
+		t.Errorf("Unexpected numbers: %d %d %d %g", numbers.Count(), numbers.Total(), numbers.Offset(), numbers.Ratio())
+	}
+
+	expected := map[string]string{"count": "-2000000", "offset": "int64 9007199254740993", "ratio": "2000000.0", "total": "uint32 4000000000"}
+
+	for key, text := range expected { // Text a DconfBackend would write
+		if value, _ := schema.Get(key); value == nil || value.Val != text {
+			t.Errorf("Expected %s to be written as %s, got %+v", key, text, value)
+		}
+	}
+}
@@ -0,0 +1,521 @@
+/* gogen.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// GenerateGo writes Go bindings for GSettings schemas, so tools get compile-time safety rather than key names and GVariant text
+// Each schema becomes a type with a getter and setter per key, reading and writing through a Backend, like a Schema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// goGenHelpers are the helpers every generated file uses to convert between Go values and the GVariant text format
+const goGenHelpers = `
+// gsettingsGet will return the text of the key at the path, and whether it is set
+func gsettingsGet(backend libdconf.Backend, keyPath string) (string, bool) {
+	value, getErr := backend.Get(keyPath)
+
+	if getErr != nil || value == nil {
+		return "", false
+	}
+
+	text := value.Val
+
+	if parsed, parseErr := libdconf.NewSchemaType(text); parseErr != nil || !parsed.Matches(value) { // Val is out of date, so format the value
+		text = value.String()
+	}
+
+	return strings.TrimSpace(text), true
+}
+
+// gsettingsSet will set the key at the path to the value in the GVariant text format
+func gsettingsSet(backend libdconf.Backend, keyPath string, text string) error {
+	value, parseErr := libdconf.NewSchemaType(text)
+
+	if parseErr != nil {
+		return parseErr
+	}
+
+	return backend.Set(keyPath, value)
+}
+
+// gsettingsNumber will return the number without any type annotation, like 5 for uint32 5
+func gsettingsNumber(text string) string {
+	fields := strings.Fields(text)
+
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return fields[len(fields)-1]
+}
+
+// gsettingsDouble will format the double so it is not mistaken for an integer
+func gsettingsDouble(value float64) string {
+	text := strconv.FormatFloat(value, 'g', -1, 64)
+
+	if value == math.Trunc(value) && math.Abs(value) < 1e21 { // Integral, so avoid an exponent like 1e+06
+		text = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	if !strings.ContainsAny(text, ".eIN") {
+		text += ".0"
+	}
+
+	return text
+}
+
+// gsettingsStrings will decode the array of strings, and whether it is one
+func gsettingsStrings(text string) ([]string, bool) {
+	items, splitErr := libdconf.SplitArray(text)
+
+	if splitErr != nil {
+		return nil, false
+	}
+
+	strs := make([]string, 0, len(items))
+
+	for _, item := range items {
+		str, unquoteErr := libdconf.UnquoteString(item)
+
+		if unquoteErr != nil {
+			return nil, false
+		}
+
+		strs = append(strs, str)
+	}
+
+	return strs, true
+}
+
+// gsettingsQuoteStrings will encode the strings as an array of strings
+func gsettingsQuoteStrings(strs []string) string {
+	if len(strs) == 0 {
+		return "@as []"
+	}
+
+	quoted := make([]string, 0, len(strs))
+
+	for _, str := range strs {
+		quoted = append(quoted, libdconf.QuoteString(str))
+	}
+
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+`
+
+// goGenInt is how a GVariant integer type is read and written in Go
+type goGenInt struct {
+	annotation string // Type keyword, empty for int32 as it needs none
+	bits       int
+	goType     string
+	unsigned   bool
+}
+
+// goGenInts are the GVariant integer types, by type string
+var goGenInts = map[string]goGenInt{
+	"h": {annotation: "handle ", bits: 32, goType: "int32"},
+	"i": {bits: 32, goType: "int32"},
+	"n": {annotation: "int16 ", bits: 16, goType: "int16"},
+	"q": {annotation: "uint16 ", bits: 16, goType: "uint16", unsigned: true},
+	"t": {annotation: "uint64 ", bits: 64, goType: "uint64", unsigned: true},
+	"u": {annotation: "uint32 ", bits: 32, goType: "uint32", unsigned: true},
+	"x": {annotation: "int64 ", bits: 64, goType: "int64"},
+	"y": {annotation: "byte ", bits: 8, goType: "byte", unsigned: true},
+}
+
+// goGenerator writes the Go bindings of a GSchemaSet
+type goGenerator struct {
+	enums       map[string]string            // Go type of each enum and flags used, by ID
+	identifiers map[string]string            // What each package level identifier was generated for, to catch conflicts
+	nicks       map[string]map[string]string // Go constant of each nick, by enum ID
+	opts        GoGenOptions
+	out         bytes.Buffer
+	schemas     map[string]string // Go type of each schema to generate, by ID
+	set         *GSchemaSet
+}
+
+// GenerateGo will generate Go bindings for the schemas of the set, returning the formatted source of a Go file
+// Each schema is a type created from a Backend, at its own path or, for relocatable schemas, one provided to its constructor,
+// with a getter returning the key's value (or default, if unset or invalid) and a setter per key, and a method per child
+// Enums and flags are string types with a constant per nick. Keys of types without a Go equivalent, like tuples, are
+// read and written as GVariant text
+func GenerateGo(set *GSchemaSet, opts GoGenOptions) ([]byte, error) {
+	gen := &goGenerator{
+		enums:       make(map[string]string),
+		identifiers: make(map[string]string),
+		nicks:       make(map[string]map[string]string),
+		opts:        opts,
+		schemas:     make(map[string]string),
+		set:         set,
+	}
+
+	if gen.opts.Package == "" {
+		gen.opts.Package = "gsettings"
+	}
+
+	ids := opts.Schemas
+
+	if len(ids) == 0 {
+		ids = set.IDs()
+	}
+
+	for _, id := range ids {
+		if genErr := gen.addSchema(id, make(map[string]bool)); genErr != nil {
+			return nil, genErr
+		}
+	}
+
+	gen.out.WriteString("// Code generated by libdconf-gen from GSettings schemas. DO NOT EDIT.\n\n")
+	gen.out.WriteString("package " + gen.opts.Package + "\n\n")
+	gen.out.WriteString("import (\n\t\"math\"\n\t\"strconv\"\n\t\"strings\"\n\n\t\"github.com/JoshStrobl/libdconf\"\n)\n")
+
+	for _, id := range goGenSorted(gen.enums) {
+		gen.writeEnum(set.Enums[id])
+	}
+
+	for _, id := range goGenSorted(gen.schemas) {
+		if genErr := gen.writeSchema(set.Schemas[id]); genErr != nil {
+			return nil, genErr
+		}
+	}
+
+	gen.out.WriteString(goGenHelpers)
+	return format.Source(gen.out.Bytes())
+}
+
+// addSchema will add the schema, along with its children and the enums and flags of its keys, to what we generate
+func (gen *goGenerator) addSchema(id string, visiting map[string]bool) error {
+	if _, added := gen.schemas[id]; added || visiting[id] {
+		return nil
+	}
+
+	schema := gen.set.Schemas[id]
+
+	if schema == nil {
+		return fmt.Errorf("%w: %s", ErrGSchemaNotFound, id)
+	}
+
+	name := gen.typeName(id)
+
+	if claimErr := gen.claim(name, "schema "+id); claimErr != nil {
+		return claimErr
+	}
+
+	if claimErr := gen.claim("New"+name, "constructor of schema "+id); claimErr != nil {
+		return claimErr
+	}
+
+	gen.schemas[id] = name
+	visiting[id] = true
+
+	for _, key := range schema.Keys {
+		if enumID := key.Enum + key.Flags; enumID != "" {
+			if addErr := gen.addEnum(enumID); addErr != nil {
+				return addErr
+			}
+		}
+	}
+
+	for _, child := range schema.Children {
+		if addErr := gen.addSchema(child.Schema, visiting); addErr != nil {
+			return addErr
+		}
+	}
+
+	return nil
+}
+
+// addEnum will add the enum or flags to what we generate, with a constant per nick
+func (gen *goGenerator) addEnum(id string) error {
+	if _, added := gen.enums[id]; added {
+		return nil
+	}
+
+	enum := gen.set.Enums[id]
+
+	if enum == nil {
+		return fmt.Errorf("%w: enum %s", ErrGSchemaNotFound, id)
+	}
+
+	name := gen.typeName(id)
+
+	if claimErr := gen.claim(name, "enum "+id); claimErr != nil {
+		return claimErr
+	}
+
+	gen.enums[id] = name
+	gen.nicks[id] = make(map[string]string)
+
+	for _, value := range enum.Values {
+		constant := name + goGenName(value.Nick)
+
+		if claimErr := gen.claim(constant, "nick "+value.Nick+" of "+id); claimErr != nil {
+			return claimErr
+		}
+
+		gen.nicks[id][value.Nick] = constant
+	}
+
+	return nil
+}
+
+// claim will claim the package level identifier for what is described, failing if it has already been claimed
+func (gen *goGenerator) claim(identifier string, what string) error {
+	if existing, claimed := gen.identifiers[identifier]; claimed {
+		return fmt.Errorf("%w: %s for both %s and %s", ErrGoGenConflict, identifier, existing, what)
+	}
+
+	gen.identifiers[identifier] = what
+	return nil
+}
+
+// typeName will return the Go type name of the schema or enum ID, with our TrimPrefix trimmed
+func (gen *goGenerator) typeName(id string) string {
+	name := goGenName(strings.TrimPrefix(id, gen.opts.TrimPrefix))
+
+	if name == "" || !unicode.IsLetter(rune(name[0])) { // Not a valid exported identifier alone
+		name = "Schema" + name
+	}
+
+	return name
+}
+
+// writeEnum will write the type of the enum or flags and its constants
+func (gen *goGenerator) writeEnum(enum *GSchemaEnum) {
+	name := gen.enums[enum.ID]
+	kind := "enum"
+
+	if enum.Flags {
+		kind = "flags"
+	}
+
+	fmt.Fprintf(&gen.out, "\n// %s is a nick of the %s %s\ntype %s string\n\nconst (\n", name, enum.ID, kind, name)
+
+	for _, value := range enum.Values {
+		fmt.Fprintf(&gen.out, "\t%s %s = %s // %d\n", gen.nicks[enum.ID][value.Nick], name, strconv.Quote(value.Nick), value.Value)
+	}
+
+	gen.out.WriteString(")\n")
+}
+
+// writeSchema will write the type of the schema, its constructor, and its accessors and children
+func (gen *goGenerator) writeSchema(schema *GSchema) error {
+	name := gen.schemas[schema.ID]
+	methods := make(map[string]string)
+
+	if schema.Relocatable() {
+		fmt.Fprintf(&gen.out, "\n// %s is the relocatable %s GSettings schema\n", name, schema.ID)
+	} else {
+		fmt.Fprintf(&gen.out, "\n// %s is the %s GSettings schema, at %s\n", name, schema.ID, schema.Path)
+	}
+
+	fmt.Fprintf(&gen.out, "type %s struct {\n\tbackend libdconf.Backend\n\tpath    string\n}\n", name)
+
+	if schema.Relocatable() {
+		fmt.Fprintf(&gen.out, "\n// New%[1]s will return the %[2]s schema at the path, reading and writing through the backend\n", name, schema.ID)
+		fmt.Fprintf(&gen.out, "func New%s(backend libdconf.Backend, path string) *%s {\n", name, name)
+		fmt.Fprintf(&gen.out, "\treturn &%s{backend: backend, path: strings.TrimSuffix(path, \"/\") + \"/\"}\n}\n", name)
+	} else {
+		fmt.Fprintf(&gen.out, "\n// New%[1]s will return the %[2]s schema, reading and writing through the backend\n", name, schema.ID)
+		fmt.Fprintf(&gen.out, "func New%s(backend libdconf.Backend) *%s {\n", name, name)
+		fmt.Fprintf(&gen.out, "\treturn &%s{backend: backend, path: %s}\n}\n", name, strconv.Quote(schema.Path))
+	}
+
+	fmt.Fprintf(&gen.out, "\n// Path will return the path of the schema\nfunc (settings *%s) Path() string {\n\treturn settings.path\n}\n", name)
+	methods["Path"] = "the path"
+
+	for _, child := range schema.Children {
+		method := goGenName(child.Name)
+
+		if existing, claimed := methods[method]; claimed || method == "" {
+			return fmt.Errorf("%w: %s.%s for both %s and child %s", ErrGoGenConflict, name, method, existing, child.Name)
+		}
+
+		methods[method] = "child " + child.Name
+		fmt.Fprintf(&gen.out, "\n// %s will return the %s child, the %s schema\n", method, child.Name, child.Schema)
+		fmt.Fprintf(&gen.out, "func (settings *%s) %s() *%s {\n", name, method, gen.schemas[child.Schema])
+		fmt.Fprintf(&gen.out, "\treturn &%s{backend: settings.backend, path: settings.path + %s}\n}\n", gen.schemas[child.Schema], strconv.Quote(child.Name+"/"))
+	}
+
+	keyNames := make([]string, 0, len(schema.Keys))
+
+	for keyName := range schema.Keys {
+		keyNames = append(keyNames, keyName)
+	}
+
+	sort.Strings(keyNames)
+
+	for _, keyName := range keyNames {
+		key := schema.Keys[keyName]
+		getter := goGenName(keyName)
+
+		for _, method := range []string{getter, "Set" + getter} {
+			if existing, claimed := methods[method]; claimed || getter == "" {
+				return fmt.Errorf("%w: %s.%s for both %s and key %s", ErrGoGenConflict, name, method, existing, keyName)
+			}
+
+			methods[method] = "key " + keyName
+		}
+
+		if writeErr := gen.writeKey(name, getter, key); writeErr != nil {
+			return fmt.Errorf("%s %s: %w", schema.ID, keyName, writeErr)
+		}
+	}
+
+	return nil
+}
+
+// writeKey will write the getter and setter of the key
+func (gen *goGenerator) writeKey(name string, getter string, key *GSchemaKey) error {
+	defaultValue, parseErr := parseGVariantText(key.Default)
+
+	if parseErr != nil {
+		return fmt.Errorf("%w: default: %s", ErrGSchemaInvalid, parseErr)
+	}
+
+	var goType, defaultGo, decode, encode string
+	intType, isInt := goGenInts[key.Type]
+
+	switch {
+	case key.Enum != "":
+		goType = gen.enums[key.Enum]
+		defaultGo = gen.nickGo(key.Enum, goType, defaultValue.text)
+		decode = "if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {\n\treturn " + goType + "(value)\n}"
+		encode = "libdconf.QuoteString(string(value))"
+	case key.Flags != "":
+		goType = "[]" + gen.enums[key.Flags]
+		nicks := make([]string, 0, len(defaultValue.items))
+
+		for _, item := range defaultValue.items {
+			nicks = append(nicks, gen.nickGo(key.Flags, gen.enums[key.Flags], item.text))
+		}
+
+		defaultGo = goType + "{" + strings.Join(nicks, ", ") + "}"
+		decode = "if nicks, valid := gsettingsStrings(text); valid {\n\tvalue := make(" + goType + ", 0, len(nicks))\n\n\tfor _, nick := range nicks {\n\t\tvalue = append(value, " + gen.enums[key.Flags] + "(nick))\n\t}\n\n\treturn value\n}"
+		encode = "gsettingsQuoteStrings(nicks)"
+	case key.Type == "b":
+		goType, defaultGo = "bool", defaultValue.text
+		decode = "if value, parseErr := strconv.ParseBool(text); parseErr == nil {\n\treturn value\n}"
+		encode = "strconv.FormatBool(value)"
+	case isInt:
+		goType, defaultGo = intType.goType, defaultValue.text
+		parse, format, wide := "ParseInt", "FormatInt", "int64"
+
+		if intType.unsigned {
+			parse, format, wide = "ParseUint", "FormatUint", "uint64"
+		}
+
+		decode = fmt.Sprintf("if value, parseErr := strconv.%s(gsettingsNumber(text), 0, %d); parseErr == nil {\n\treturn %s(value)\n}", parse, intType.bits, goType)
+		encode = fmt.Sprintf("%sstrconv.%s(%s(value), 10)", strconv.Quote(intType.annotation)+" + ", format, wide)
+
+		if intType.annotation == "" {
+			encode = fmt.Sprintf("strconv.%s(%s(value), 10)", format, wide)
+		}
+	case key.Type == "d":
+		goType, defaultGo = "float64", defaultValue.text
+		decode = "if value, parseErr := strconv.ParseFloat(gsettingsNumber(text), 64); parseErr == nil {\n\treturn value\n}"
+		encode = "gsettingsDouble(value)"
+	case key.Type == "s" || key.Type == "o" || key.Type == "g":
+		goType, defaultGo = "string", strconv.Quote(defaultValue.text)
+		annotation := map[string]string{"s": "", "o": "objectpath ", "g": "signature "}[key.Type]
+		decode = "if value, unquoteErr := libdconf.UnquoteString(strings.TrimPrefix(text, " + strconv.Quote(annotation) + ")); unquoteErr == nil {\n\treturn value\n}"
+		encode = strconv.Quote(annotation) + " + libdconf.QuoteString(value)"
+
+		if annotation == "" {
+			decode = "if value, unquoteErr := libdconf.UnquoteString(text); unquoteErr == nil {\n\treturn value\n}"
+			encode = "libdconf.QuoteString(value)"
+		}
+	case key.Type == "as":
+		goType = "[]string"
+		items := make([]string, 0, len(defaultValue.items))
+
+		for _, item := range defaultValue.items {
+			items = append(items, strconv.Quote(item.text))
+		}
+
+		defaultGo = "[]string{" + strings.Join(items, ", ") + "}"
+		decode = "if value, valid := gsettingsStrings(text); valid {\n\treturn value\n}"
+		encode = "gsettingsQuoteStrings(value)"
+	default: // No Go equivalent, so GVariant text
+		goType, defaultGo = "string", strconv.Quote(key.Default)
+		decode = "return text"
+		encode = "value"
+	}
+
+	keyPath := "settings.path + " + strconv.Quote(key.Name)
+	description := key.Name
+
+	if key.Summary != "" {
+		description += " (" + strings.TrimSuffix(key.Summary, ".") + ")"
+	}
+
+	fmt.Fprintf(&gen.out, "\n// %s will return %s, or its default of %s if unset\n", getter, description, collapseWhitespace(key.Default))
+	fmt.Fprintf(&gen.out, "func (settings *%s) %s() %s {\n", name, getter, goType)
+	fmt.Fprintf(&gen.out, "if text, set := gsettingsGet(settings.backend, %s); set {\n%s\n}\n\nreturn %s\n}\n", keyPath, decode, defaultGo)
+
+	fmt.Fprintf(&gen.out, "\n// Set%s will set %s\n", getter, key.Name)
+	fmt.Fprintf(&gen.out, "func (settings *%s) Set%s(value %s) error {\n", name, getter, goType)
+
+	if key.Flags != "" {
+		fmt.Fprintf(&gen.out, "nicks := make([]string, 0, len(value))\n\nfor _, nick := range value {\n\tnicks = append(nicks, string(nick))\n}\n\n")
+	}
+
+	fmt.Fprintf(&gen.out, "return gsettingsSet(settings.backend, %s, %s)\n}\n", keyPath, encode)
+	return nil
+}
+
+// nickGo will return the Go constant of the nick of the enum, or a conversion to its type for aliases and unknown nicks
+func (gen *goGenerator) nickGo(enumID string, goType string, nick string) string {
+	if constant, exists := gen.nicks[enumID][nick]; exists {
+		return constant
+	}
+
+	return goType + "(" + strconv.Quote(nick) + ")"
+}
+
+// goGenName will convert the ID, name or nick to an exported Go identifier, like DarkTheme for dark-theme
+func goGenName(name string) string {
+	var identifier strings.Builder
+
+	for _, part := range strings.FieldsFunc(name, func(char rune) bool { return !unicode.IsLetter(char) && !unicode.IsDigit(char) }) {
+		runes := []rune(part)
+		identifier.WriteRune(unicode.ToUpper(runes[0]))
+		identifier.WriteString(string(runes[1:]))
+	}
+
+	return identifier.String()
+}
+
+// goGenSorted will return the keys of the map, sorted
+func goGenSorted(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
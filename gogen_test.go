@@ -0,0 +1,109 @@
+/* gogen_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestGenerateGo will test that our example bindings, generated with go generate, are up to date
+func TestGenerateGo(t *testing.T) {
+	set, _ := LoadGSchemaDir("examples/schemas")
+	examples, _ := LoadGSchemaDir("examples/gsettings")
+	set.Merge(examples)
+
+	source, genErr := GenerateGo(set, GoGenOptions{Package: "gsettings", TrimPrefix: "com.solus-project."})
+
+	if genErr != nil {
+		t.Fatalf("Failed to generate: %s", genErr)
+	}
+
+	expected, _ := os.ReadFile("examples/gsettings/gsettings_gen.go")
+
+	if !bytes.Equal(source, expected) {
+		t.Error("Expected examples/gsettings/gsettings_gen.go to match, run go generate in examples/gsettings")
+	}
+}
+
+// TestGenerateGoSchemas will test generating a subset of schemas, which includes their children and enums
+func TestGenerateGoSchemas(t *testing.T) {
+	set, _ := ParseGSchemaXML(GSchemaXML)
+	source, genErr := GenerateGo(set, GoGenOptions{Schemas: []string{"org.example"}, TrimPrefix: "org."})
+
+	if genErr != nil {
+		t.Fatalf("Failed to generate: %s", genErr)
+	}
+
+	code := string(source)
+
+	for _, expected := range []string{"package gsettings\n", "type Example struct", "func NewExample(backend libdconf.Backend) *Example", "type ExampleWindow struct", "func NewExampleWindow(backend libdconf.Backend, path string) *ExampleWindow", "type ExampleFeatures string", "type ExampleMode string", "ExampleModeOn"} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected %q in the generated code", expected)
+		}
+	}
+
+	if strings.Contains(code, "type ExampleBase struct") {
+		t.Error("Expected org.example.base to not be generated")
+	}
+
+	if _, genErr = GenerateGo(set, GoGenOptions{Schemas: []string{"org.example.missing"}}); !errors.Is(genErr, ErrGSchemaNotFound) {
+		t.Errorf("Expected ErrGSchemaNotFound, got %v instead.", genErr)
+	}
+}
+
+// TestGenerateGoConflicts will test that identifiers generated more than once are rejected
+func TestGenerateGoConflicts(t *testing.T) {
+	conflicts := []string{
+		`<schemalist><schema id="org.example.a-b"/><schema id="org.example.a.b"/></schemalist>`,
+		`<schemalist><schema id="org.example"><key name="a-b" type="b"><default>true</default></key><key name="a.b" type="b"><default>true</default></key></schema></schemalist>`,
+		`<schemalist><schema id="org.example"><key name="path" type="b"><default>true</default></key></schema></schemalist>`,
+		`<schemalist><schema id="org.example"><key name="window" type="b"><default>true</default></key><child name="window" schema="org.example"/></schema></schemalist>`,
+	}
+
+	for _, content := range conflicts {
+		set, parseErr := ParseGSchemaXML([]byte(content))
+
+		if parseErr != nil {
+			t.Fatalf("Failed to parse %s: %s", content, parseErr)
+		}
+
+		if _, genErr := GenerateGo(set, GoGenOptions{}); !errors.Is(genErr, ErrGoGenConflict) {
+			t.Errorf("Expected ErrGoGenConflict for %s, got %v instead.", content, genErr)
+		}
+	}
+}
+
+// TestGoGenName will test converting names to Go identifiers
+func TestGoGenName(t *testing.T) {
+	tests := map[string]string{
+		"dark-theme":          "DarkTheme",
+		"budgie-panel.applet": "BudgiePanelApplet",
+		"show_24h":            "Show24h",
+		"élan":                "Élan",
+	}
+
+	for name, expected := range tests {
+		if identifier := goGenName(name); identifier != expected {
+			t.Errorf("Expected %s to be %s, got %s instead.", name, expected, identifier)
+		}
+	}
+}
@@ -0,0 +1,493 @@
+/* gschema.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// GSettings schemas describe what the keys at a dconf path mean: their types, defaults, and what values they accept
+// Schemas without a path are relocatable, and can be used at any path (like one per applet at applets/{UUID}/)
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gschemaXMLList is the <schemalist> root of a gschema.xml file
+type gschemaXMLList struct {
+	Enums         []gschemaXMLEnum   `xml:"enum"`
+	Flags         []gschemaXMLEnum   `xml:"flags"`
+	GettextDomain string             `xml:"gettext-domain,attr"`
+	Schemas       []gschemaXMLSchema `xml:"schema"`
+}
+
+// gschemaXMLEnum is an <enum> or <flags>
+type gschemaXMLEnum struct {
+	ID     string `xml:"id,attr"`
+	Values []struct {
+		Nick  string `xml:"nick,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"value"`
+}
+
+// gschemaXMLSchema is a <schema>
+type gschemaXMLSchema struct {
+	Children []struct {
+		Name   string `xml:"name,attr"`
+		Schema string `xml:"schema,attr"`
+	} `xml:"child"`
+	Extends       string          `xml:"extends,attr"`
+	GettextDomain string          `xml:"gettext-domain,attr"`
+	ID            string          `xml:"id,attr"`
+	Keys          []gschemaXMLKey `xml:"key"`
+	Overrides     []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"override"`
+	Path string `xml:"path,attr"`
+}
+
+// gschemaXMLKey is a <key>
+type gschemaXMLKey struct {
+	Aliases []struct {
+		Target string `xml:"target,attr"`
+		Value  string `xml:"value,attr"`
+	} `xml:"aliases>alias"`
+	Choices []struct {
+		Value string `xml:"value,attr"`
+	} `xml:"choices>choice"`
+	Default     string `xml:"default"`
+	Description string `xml:"description"`
+	Enum        string `xml:"enum,attr"`
+	Flags       string `xml:"flags,attr"`
+	Name        string `xml:"name,attr"`
+	Range       *struct {
+		Max string `xml:"max,attr"`
+		Min string `xml:"min,attr"`
+	} `xml:"range"`
+	Summary string `xml:"summary"`
+	Type    string `xml:"type,attr"`
+}
+
+// NewGSchemaSet will create an empty GSchemaSet
+func NewGSchemaSet() *GSchemaSet {
+	return &GSchemaSet{
+		Enums:   make(map[string]*GSchemaEnum),
+		Schemas: make(map[string]*GSchema),
+	}
+}
+
+// ParseGSchemaXML will parse the content of a gschema.xml file into a GSchemaSet
+func ParseGSchemaXML(content []byte) (set *GSchemaSet, parseErr error) {
+	if len(content) == 0 { // content not specified or has no content
+		parseErr = ErrNoContentProvided
+		return
+	}
+
+	var list gschemaXMLList
+	if parseErr = xml.Unmarshal(content, &list); parseErr != nil {
+		parseErr = fmt.Errorf("%w: %s", ErrGSchemaInvalid, parseErr)
+		return
+	}
+
+	set = NewGSchemaSet()
+
+	for index, xmlEnum := range append(append([]gschemaXMLEnum{}, list.Enums...), list.Flags...) {
+		enum, enumErr := xmlEnum.toGSchemaEnum(index >= len(list.Enums)) // Flags come after our enums
+
+		if enumErr != nil {
+			return nil, enumErr
+		}
+
+		if _, exists := set.Enums[enum.ID]; exists {
+			return nil, fmt.Errorf("%w: enum %s", ErrGSchemaDuplicate, enum.ID)
+		}
+
+		set.Enums[enum.ID] = enum
+	}
+
+	for _, xmlSchema := range list.Schemas {
+		if xmlSchema.GettextDomain == "" { // Inherit from the schemalist
+			xmlSchema.GettextDomain = list.GettextDomain
+		}
+
+		schema, schemaErr := xmlSchema.toGSchema()
+
+		if schemaErr != nil {
+			return nil, schemaErr
+		}
+
+		if _, exists := set.Schemas[schema.ID]; exists {
+			return nil, fmt.Errorf("%w: schema %s", ErrGSchemaDuplicate, schema.ID)
+		}
+
+		set.Schemas[schema.ID] = schema
+	}
+
+	set.resolveExtends()
+	return
+}
+
+// LoadGSchemaDir will parse every gschema.xml file in the directory, like /usr/share/glib-2.0/schemas, into one GSchemaSet
+func LoadGSchemaDir(dir string) (set *GSchemaSet, loadErr error) {
+	var files []string
+	if files, loadErr = filepath.Glob(filepath.Join(dir, "*.gschema.xml")); loadErr != nil {
+		return
+	}
+
+	sort.Strings(files) // Same order as glib-compile-schemas
+	set = NewGSchemaSet()
+
+	for _, file := range files {
+		content, readErr := os.ReadFile(file)
+
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		fileSet, parseErr := ParseGSchemaXML(content)
+
+		if parseErr != nil {
+			return nil, fmt.Errorf("%s: %w", file, parseErr)
+		}
+
+		if mergeErr := set.Merge(fileSet); mergeErr != nil {
+			return nil, fmt.Errorf("%s: %w", file, mergeErr)
+		}
+	}
+
+	return
+}
+
+// Merge will add the schemas, enums and flags of the other GSchemaSet to ours
+// IDs must be unique across both sets, as with glib-compile-schemas
+func (set *GSchemaSet) Merge(other *GSchemaSet) error {
+	for id := range other.Enums {
+		if _, exists := set.Enums[id]; exists {
+			return fmt.Errorf("%w: enum %s", ErrGSchemaDuplicate, id)
+		}
+	}
+
+	for id := range other.Schemas {
+		if _, exists := set.Schemas[id]; exists {
+			return fmt.Errorf("%w: schema %s", ErrGSchemaDuplicate, id)
+		}
+	}
+
+	for id, enum := range other.Enums {
+		set.Enums[id] = enum
+	}
+
+	for id, schema := range other.Schemas {
+		set.Schemas[id] = schema
+	}
+
+	set.mounts = append(set.mounts, other.mounts...)
+
+	set.resolveExtends() // Schemas may extend one from the other set
+	return nil
+}
+
+// IDs will return the sorted IDs of our schemas
+func (set *GSchemaSet) IDs() []string {
+	ids := make([]string, 0, len(set.Schemas))
+
+	for id := range set.Schemas {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+// Enum will return the enum or flags with the ID, nil if there is none
+func (set *GSchemaSet) Enum(id string) *GSchemaEnum {
+	return set.Enums[id]
+}
+
+// Schema will return the schema with the ID, nil if there is none
+func (set *GSchemaSet) Schema(id string) *GSchema {
+	return set.Schemas[id]
+}
+
+// Mount will mount the relocatable schema at every dconf directory matching the glob, as applications do with their own code
+// Globs are as with NewSelector, but of full paths ending in /, like /com/solus-project/budgie-panel/applets/*/
+func (set *GSchemaSet) Mount(path string, schemaID string) error {
+	if !strings.HasPrefix(path, "/") || !strings.HasSuffix(path, "/") {
+		return fmt.Errorf("%w: %q is not a full path ending in /", ErrInvalidKeyPath, path)
+	}
+
+	if _, compileErr := regexp.Compile(globToRegexp(path)); compileErr != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidKeyPath, compileErr)
+	}
+
+	if schema := set.Schemas[schemaID]; schema == nil {
+		return fmt.Errorf("%w: %s", ErrGSchemaNotFound, schemaID)
+	} else if !schema.Relocatable() {
+		return fmt.Errorf("%w: %s is not relocatable", ErrGSchemaInvalid, schemaID)
+	}
+
+	set.mounts = append(set.mounts, GSchemaMount{Path: path, Schema: schemaID})
+	return nil
+}
+
+// Mounts will return where our relocatable schemas are mounted, in the order they were mounted
+func (set *GSchemaSet) Mounts() []GSchemaMount {
+	return append([]GSchemaMount{}, set.mounts...)
+}
+
+// Relocatable will check if the GSchema is relocatable, having no fixed path
+func (schema *GSchema) Relocatable() bool {
+	return schema.Path == ""
+}
+
+// resolveExtends will copy the keys and children of extended schemas into the schemas extending them, applying any overrides
+// Schemas extending one we do not have yet are left as-is, and resolved once it is merged in
+func (set *GSchemaSet) resolveExtends() {
+	resolved := make(map[string]bool)
+
+	var resolve func(schema *GSchema, visiting map[string]bool)
+	resolve = func(schema *GSchema, visiting map[string]bool) {
+		base, exists := set.Schemas[schema.Extends]
+
+		if resolved[schema.ID] || schema.Extends == "" || !exists || visiting[base.ID] { // Nothing to do, or a cycle
+			return
+		}
+
+		visiting[schema.ID] = true
+		resolve(base, visiting) // Base may itself extend another
+
+		for name, key := range base.Keys { // Keys we redefine take precedence
+			if _, redefined := schema.Keys[name]; redefined {
+				continue
+			}
+
+			if override, overridden := schema.Overrides[name]; overridden { // Same key with our default
+				overriddenKey := *key
+				overriddenKey.Default = override
+				key = &overriddenKey
+			}
+
+			schema.Keys[name] = key
+		}
+
+		for _, child := range base.Children {
+			if !schema.hasChild(child.Name) {
+				schema.Children = append(schema.Children, child)
+			}
+		}
+
+		resolved[schema.ID] = true
+	}
+
+	for _, id := range set.IDs() {
+		resolve(set.Schemas[id], make(map[string]bool))
+	}
+}
+
+// hasChild will check if the GSchema has a child of the provided name
+func (schema *GSchema) hasChild(name string) bool {
+	for _, child := range schema.Children {
+		if child.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// toGSchemaEnum will convert the <enum> or <flags> to a GSchemaEnum
+func (xmlEnum gschemaXMLEnum) toGSchemaEnum(flags bool) (*GSchemaEnum, error) {
+	if xmlEnum.ID == "" {
+		return nil, fmt.Errorf("%w: enum without an id", ErrGSchemaInvalid)
+	}
+
+	enum := &GSchemaEnum{
+		Flags:  flags,
+		ID:     xmlEnum.ID,
+		Values: []GSchemaEnumValue{},
+	}
+
+	for _, xmlValue := range xmlEnum.Values {
+		value, parseErr := strconv.ParseInt(strings.TrimSpace(xmlValue.Value), 0, 32)
+
+		if parseErr != nil || xmlValue.Nick == "" {
+			return nil, fmt.Errorf("%w: enum %s: invalid value %q for nick %q", ErrGSchemaInvalid, enum.ID, xmlValue.Value, xmlValue.Nick)
+		}
+
+		enum.Values = append(enum.Values, GSchemaEnumValue{Nick: xmlValue.Nick, Value: int32(value)})
+	}
+
+	return enum, nil
+}
+
+// toGSchema will convert the <schema> to a GSchema
+func (xmlSchema gschemaXMLSchema) toGSchema() (*GSchema, error) {
+	if xmlSchema.ID == "" {
+		return nil, fmt.Errorf("%w: schema without an id", ErrGSchemaInvalid)
+	}
+
+	if path := xmlSchema.Path; path != "" && (!strings.HasPrefix(path, "/") || !strings.HasSuffix(path, "/") || strings.Contains(path, "//")) {
+		return nil, fmt.Errorf("%w: schema %s: path %q must start and end with / and not contain //", ErrGSchemaInvalid, xmlSchema.ID, path)
+	}
+
+	schema := &GSchema{
+		Children:      []GSchemaChild{},
+		Extends:       xmlSchema.Extends,
+		GettextDomain: xmlSchema.GettextDomain,
+		ID:            xmlSchema.ID,
+		Keys:          make(map[string]*GSchemaKey),
+		Overrides:     make(map[string]string),
+		Path:          xmlSchema.Path,
+	}
+
+	for _, xmlOverride := range xmlSchema.Overrides {
+		if xmlOverride.Name == "" || xmlSchema.Extends == "" {
+			return nil, fmt.Errorf("%w: schema %s: override needs a name and a schema to extend", ErrGSchemaInvalid, schema.ID)
+		}
+
+		schema.Overrides[xmlOverride.Name] = strings.TrimSpace(xmlOverride.Value)
+	}
+
+	for _, xmlChild := range xmlSchema.Children {
+		if xmlChild.Name == "" || xmlChild.Schema == "" {
+			return nil, fmt.Errorf("%w: schema %s: child needs a name and schema", ErrGSchemaInvalid, schema.ID)
+		}
+
+		schema.Children = append(schema.Children, GSchemaChild{Name: xmlChild.Name, Schema: xmlChild.Schema})
+	}
+
+	for _, xmlKey := range xmlSchema.Keys {
+		key, keyErr := xmlKey.toGSchemaKey()
+
+		if keyErr != nil {
+			return nil, fmt.Errorf("schema %s: %w", schema.ID, keyErr)
+		}
+
+		if _, exists := schema.Keys[key.Name]; exists {
+			return nil, fmt.Errorf("%w: schema %s: key %s declared twice", ErrGSchemaInvalid, schema.ID, key.Name)
+		}
+
+		schema.Keys[key.Name] = key
+	}
+
+	return schema, nil
+}
+
+// toGSchemaKey will convert the <key> to a GSchemaKey
+func (xmlKey gschemaXMLKey) toGSchemaKey() (*GSchemaKey, error) {
+	key := &GSchemaKey{
+		Aliases:     make(map[string]string),
+		Choices:     []string{},
+		Default:     strings.TrimSpace(xmlKey.Default),
+		Description: collapseWhitespace(xmlKey.Description),
+		Enum:        xmlKey.Enum,
+		Flags:       xmlKey.Flags,
+		Name:        xmlKey.Name,
+		Summary:     collapseWhitespace(xmlKey.Summary),
+		Type:        xmlKey.Type,
+	}
+
+	if validateErr := ValidateKeyName(key.Name); validateErr != nil {
+		return nil, fmt.Errorf("%w: %s", ErrGSchemaInvalid, validateErr)
+	}
+
+	switch { // Exactly one of type, enum or flags
+	case key.Type != "" && key.Enum == "" && key.Flags == "":
+		if !ValidGVariantType(key.Type) {
+			return nil, fmt.Errorf("%w: key %s: invalid type %q", ErrGSchemaInvalid, key.Name, key.Type)
+		}
+	case key.Type == "" && key.Enum != "" && key.Flags == "":
+		key.Type = "s"
+	case key.Type == "" && key.Enum == "" && key.Flags != "":
+		key.Type = "as"
+	default:
+		return nil, fmt.Errorf("%w: key %s: needs exactly one of type, enum or flags", ErrGSchemaInvalid, key.Name)
+	}
+
+	if key.Default == "" {
+		return nil, fmt.Errorf("%w: key %s: no default", ErrGSchemaInvalid, key.Name)
+	}
+
+	for _, choice := range xmlKey.Choices {
+		key.Choices = append(key.Choices, choice.Value)
+	}
+
+	for _, alias := range xmlKey.Aliases {
+		key.Aliases[alias.Value] = alias.Target
+	}
+
+	if xmlKey.Range != nil {
+		key.Range = &GSchemaRange{Max: xmlKey.Range.Max, Min: xmlKey.Range.Min}
+	}
+
+	return key, nil
+}
+
+// ValidGVariantType will check if the string is a single, complete GVariant type string, like as or a{sv}
+func ValidGVariantType(typeString string) bool {
+	end, valid := scanGVariantType(typeString, 0)
+	return valid && end == len(typeString)
+}
+
+// scanGVariantType will scan a single complete type starting at the index, returning the index after it
+func scanGVariantType(typeString string, index int) (int, bool) {
+	if index >= len(typeString) {
+		return index, false
+	}
+
+	switch char := typeString[index]; {
+	case strings.IndexByte("bynqiuxthdsogv*?r", char) != -1: // Basic, variant or indefinite types
+		return index + 1, true
+	case char == 'a' || char == 'm': // Array or maybe of a type
+		return scanGVariantType(typeString, index+1)
+	case char == '(': // Tuple of any number of types
+		index++
+
+		for index < len(typeString) && typeString[index] != ')' {
+			var valid bool
+			if index, valid = scanGVariantType(typeString, index); !valid {
+				return index, false
+			}
+		}
+
+		return index + 1, index < len(typeString)
+	case char == '{': // Dictionary entry of a basic key type and any value type
+		if index+1 >= len(typeString) || strings.IndexByte("bynqiuxthdsog?", typeString[index+1]) == -1 {
+			return index, false
+		}
+
+		end, valid := scanGVariantType(typeString, index+2)
+
+		if !valid || end >= len(typeString) || typeString[end] != '}' {
+			return end, false
+		}
+
+		return end + 1, true
+	default:
+		return index, false
+	}
+}
+
+// collapseWhitespace will trim the text and collapse any runs of whitespace within it, as XML text is usually wrapped
+func collapseWhitespace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
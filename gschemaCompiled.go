@@ -0,0 +1,293 @@
+/* gschemaCompiled.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// gschemas.compiled is a GVDB file with a table per schema, keyed by schema ID
+// Each schema's table has a value per key (a tuple of its default and any options, like its range) along with
+// .path, .extends and .gettext metadata, and a value per child ending with /, holding the child's schema ID
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GSchemaInstallDir is where GSettings schemas are installed, relative to the root of a system
+const GSchemaInstallDir = "usr/share/glib-2.0/schemas"
+
+// strinfoEntry is an entry of the string info compiled for a key's choices, enum or flags
+type strinfoEntry struct {
+	alias bool   // Whether this is an alias, with value being the word index of its target
+	index int    // Word index of the entry
+	str   string // Choice, nick or alias
+	value uint32 // Enum or flags value, or word index of the alias target
+}
+
+// ParseGSchemasCompiled will read the content of a gschemas.compiled file into a GSchemaSet
+// Compiled files do not keep summaries, descriptions or the IDs of enums and flags, so each enum or flags key
+// gets a GSchemaEnum of its own, with an ID of the schema ID and key name, like com.solus-project.budgie-panel.panel/location
+func ParseGSchemasCompiled(content []byte) (set *GSchemaSet, parseErr error) {
+	if len(content) == 0 { // content not specified or has no content
+		parseErr = ErrNoContentProvided
+		return
+	}
+
+	file, root, openErr := openGVDB(content)
+
+	if openErr != nil {
+		return nil, openErr
+	}
+
+	set = NewGSchemaSet()
+
+	for _, item := range root {
+		if item.kind != 'H' { // Not a schema
+			continue
+		}
+
+		if parseErr = set.addCompiledSchema(file, item); parseErr != nil {
+			return nil, fmt.Errorf("schema %s: %w", item.key, parseErr)
+		}
+	}
+
+	set.resolveExtends()
+	return
+}
+
+// LoadInstalledGSchemas will load the GSettings schemas installed under the root directory, like / or the root of an image
+// The gschemas.compiled file is read if there is one, as that is what GSettings uses, otherwise the gschema.xml files are parsed
+func LoadInstalledGSchemas(root string) (*GSchemaSet, error) {
+	dir := filepath.Join(root, GSchemaInstallDir)
+	content, readErr := os.ReadFile(filepath.Join(dir, "gschemas.compiled"))
+
+	if os.IsNotExist(readErr) { // Not compiled
+		return LoadGSchemaDir(dir)
+	} else if readErr != nil {
+		return nil, readErr
+	}
+
+	return ParseGSchemasCompiled(content)
+}
+
+// addCompiledSchema will read the schema table the item points to into our set
+func (set *GSchemaSet) addCompiledSchema(file *gvdbFile, item gvdbItem) error {
+	items, tableErr := file.subtable(item)
+
+	if tableErr != nil {
+		return tableErr
+	}
+
+	schema := &GSchema{
+		Children:  []GSchemaChild{},
+		ID:        item.key,
+		Keys:      make(map[string]*GSchemaKey),
+		Overrides: make(map[string]string),
+	}
+
+	for _, keyItem := range items {
+		if keyItem.kind != 'v' { // Lists of the table's keys
+			continue
+		}
+
+		value, valueErr := file.value(keyItem)
+
+		if valueErr != nil {
+			return valueErr
+		}
+
+		switch name := keyItem.key; {
+		case name == ".path", name == ".extends", name == ".gettext", strings.HasSuffix(name, "/"):
+			str, strErr := value.str()
+
+			if strErr != nil || value.typ.kind != 's' {
+				return fmt.Errorf("%w: %s is not a string", ErrGVDBInvalid, name)
+			}
+
+			switch name {
+			case ".path":
+				schema.Path = str
+			case ".extends":
+				schema.Extends = str
+			case ".gettext":
+				schema.GettextDomain = str
+			default: // Child
+				schema.Children = append(schema.Children, GSchemaChild{Name: strings.TrimSuffix(name, "/"), Schema: str})
+			}
+		case strings.HasPrefix(name, "."): // Other metadata, like the deprecated .list-of
+		default:
+			key, keyErr := set.compiledKey(schema.ID, name, value)
+
+			if keyErr != nil {
+				return fmt.Errorf("key %s: %w", name, keyErr)
+			}
+
+			schema.Keys[name] = key
+		}
+	}
+
+	set.Schemas[schema.ID] = schema
+	return nil
+}
+
+// compiledKey will read the key from its compiled tuple of its default followed by (option byte, option value) pairs
+func (set *GSchemaSet) compiledKey(schemaID string, name string, value gvariantValue) (*GSchemaKey, error) {
+	if value.typ.kind != '(' || len(value.typ.elements) == 0 {
+		return nil, fmt.Errorf("%w: unexpected type %s", ErrGVDBInvalid, value.typ.str)
+	}
+
+	members, membersErr := value.children()
+
+	if membersErr != nil {
+		return nil, membersErr
+	}
+
+	defaultText, defaultErr := members[0].text()
+
+	if defaultErr != nil {
+		return nil, defaultErr
+	}
+
+	key := &GSchemaKey{
+		Aliases: make(map[string]string),
+		Choices: []string{},
+		Default: defaultText,
+		Name:    name,
+		Type:    members[0].typ.str,
+	}
+
+	for _, member := range members[1:] {
+		option, optionErr := member.children()
+
+		if optionErr != nil || len(option) != 2 || option[0].typ.kind != 'y' || len(option[0].data) != 1 {
+			return nil, fmt.Errorf("%w: invalid option of type %s", ErrGVDBInvalid, member.typ.str)
+		}
+
+		switch optionValue := option[1]; option[0].data[0] {
+		case 'c', 'e', 'f': // Choices, enum or flags
+			entries, strinfoErr := parseStrinfo(optionValue.data)
+
+			if strinfoErr != nil {
+				return nil, strinfoErr
+			}
+
+			key.applyStrinfo(set, schemaID, option[0].data[0], entries)
+		case 'r': // Range
+			bounds, boundsErr := optionValue.children()
+
+			if boundsErr != nil || len(bounds) != 2 {
+				return nil, fmt.Errorf("%w: invalid range", ErrGVDBInvalid)
+			}
+
+			min, minErr := bounds[0].text()
+			max, maxErr := bounds[1].text()
+
+			if minErr != nil || maxErr != nil {
+				return nil, fmt.Errorf("%w: invalid range", ErrGVDBInvalid)
+			}
+
+			key.Range = &GSchemaRange{Max: max, Min: min}
+		} // Anything else, like translation (l) or per-desktop defaults (d), is not part of our model
+	}
+
+	return key, nil
+}
+
+// applyStrinfo will add the choices, or enum or flags values, along with any aliases, to the key
+func (key *GSchemaKey) applyStrinfo(set *GSchemaSet, schemaID string, option byte, entries []strinfoEntry) {
+	byIndex := make(map[int]string)
+	var enum *GSchemaEnum
+
+	if option != 'c' { // Enum or flags
+		enum = &GSchemaEnum{
+			Flags:  option == 'f',
+			ID:     schemaID + "/" + key.Name,
+			Values: []GSchemaEnumValue{},
+		}
+
+		set.Enums[enum.ID] = enum
+
+		if enum.Flags {
+			key.Flags = enum.ID
+		} else {
+			key.Enum = enum.ID
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.alias {
+			continue
+		}
+
+		byIndex[entry.index] = entry.str
+
+		if enum != nil {
+			enum.Values = append(enum.Values, GSchemaEnumValue{Nick: entry.str, Value: int32(entry.value)})
+		} else {
+			key.Choices = append(key.Choices, entry.str)
+		}
+	}
+
+	for _, entry := range entries {
+		if target, exists := byIndex[int(entry.value)]; entry.alias && exists {
+			key.Aliases[entry.str] = target
+		}
+	}
+}
+
+// parseStrinfo will parse compiled string info, a list of 32-bit little endian words
+// Each entry is a word holding its value, followed by words holding 0xff (or 0xfe for an alias), the string and a nul,
+// padded out to a word ending in 0xff
+func parseStrinfo(data []byte) (entries []strinfoEntry, parseErr error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("%w: string info has the wrong size", ErrGVDBInvalid)
+	}
+
+	words := len(data) / 4
+
+	for index := 0; index < words; {
+		start := (index + 1) * 4
+		end := index + 1 // Word index of the last word of the string
+
+		for end < words && data[end*4+3] != 0xff {
+			end++
+		}
+
+		if end >= words || (data[start] != 0xff && data[start] != 0xfe) {
+			return nil, fmt.Errorf("%w: invalid string info", ErrGVDBInvalid)
+		}
+
+		str := data[start+1 : (end+1)*4]
+
+		if nul := strings.IndexByte(string(str), 0); nul != -1 {
+			str = str[:nul]
+		}
+
+		entries = append(entries, strinfoEntry{
+			alias: data[start] == 0xfe,
+			index: index,
+			str:   string(str),
+			value: binary.LittleEndian.Uint32(data[index*4:]),
+		})
+
+		index = end + 1
+	}
+
+	return
+}
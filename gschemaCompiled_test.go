@@ -0,0 +1,121 @@
+/* gschemaCompiled_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseGSchemasCompiled will test that our compiled example matches the schemas it was compiled from
+func TestParseGSchemasCompiled(t *testing.T) {
+	content, readErr := os.ReadFile(CompiledGSchemasFile)
+
+	if readErr != nil {
+		t.Fatalf("Failed to read: %s", readErr)
+	}
+
+	compiled, parseErr := ParseGSchemasCompiled(content)
+
+	if parseErr != nil {
+		t.Fatalf("Failed to parse: %s", parseErr)
+	}
+
+	expected, _ := LoadGSchemaDir("examples/schemas")
+	example, _ := ParseGSchemaXML(GSchemaXML)
+	expected.Merge(example)
+
+	if !reflect.DeepEqual(compiled.IDs(), expected.IDs()) {
+		t.Fatalf("Expected schemas %v, got %v instead.", expected.IDs(), compiled.IDs())
+	}
+
+	for id, schema := range expected.Schemas {
+		got := compiled.Schemas[id]
+
+		if got.Path != schema.Path || got.Extends != schema.Extends || !reflect.DeepEqual(got.Children, schema.Children) {
+			t.Errorf("Expected %s to be %+v, got %+v instead.", id, schema, got)
+		}
+
+		if len(got.Keys) != len(schema.Keys) {
+			t.Errorf("Expected %d keys in %s, got %d instead.", len(schema.Keys), id, len(got.Keys))
+		}
+
+		for name, key := range schema.Keys {
+			gotKey := got.Keys[name]
+
+			if gotKey == nil {
+				t.Errorf("Expected key %s in %s", name, id)
+				continue
+			}
+
+			if gotKey.Default != key.Default || gotKey.Type != key.Type || !reflect.DeepEqual(gotKey.Range, key.Range) || !reflect.DeepEqual(gotKey.Choices, key.Choices) || !reflect.DeepEqual(gotKey.Aliases, key.Aliases) {
+				t.Errorf("Expected %s %s to be %+v, got %+v instead.", id, name, key, gotKey)
+			}
+
+			if (key.Enum == "") != (gotKey.Enum == "") || (key.Flags == "") != (gotKey.Flags == "") {
+				t.Errorf("Expected %s %s to have enum %q and flags %q, got %q and %q instead.", id, name, key.Enum, key.Flags, gotKey.Enum, gotKey.Flags)
+			} else if enumID := key.Enum + key.Flags; enumID != "" && !reflect.DeepEqual(compiled.Enums[gotKey.Enum+gotKey.Flags].Values, expected.Enums[enumID].Values) {
+				t.Errorf("Expected %s %s to have values %v, got %v instead.", id, name, expected.Enums[enumID].Values, compiled.Enums[gotKey.Enum+gotKey.Flags].Values)
+			}
+		}
+	}
+
+	if features := compiled.Enums["org.example/features"]; features == nil || !features.Flags {
+		t.Errorf("Expected flags for org.example/features, got %+v", features)
+	}
+}
+
+// TestParseGSchemasCompiledInvalid will test that empty and corrupt files are rejected without panicking
+func TestParseGSchemasCompiledInvalid(t *testing.T) {
+	if _, parseErr := ParseGSchemasCompiled(nil); !errors.Is(parseErr, ErrNoContentProvided) {
+		t.Errorf("Expected ErrNoContentProvided, got %v instead.", parseErr)
+	}
+
+	content, _ := os.ReadFile(CompiledGSchemasFile)
+
+	for length := 0; length < len(content); length += 7 {
+		ParseGSchemasCompiled(content[:length])
+	}
+
+	for index := range content {
+		corrupt := append([]byte{}, content...)
+		corrupt[index] ^= 0xff
+		ParseGSchemasCompiled(corrupt)
+	}
+}
+
+// TestLoadInstalledGSchemas will test loading the schemas of an image, compiled or not
+func TestLoadInstalledGSchemas(t *testing.T) {
+	set, loadErr := LoadInstalledGSchemas("examples/image")
+
+	if loadErr != nil || set.Schemas["com.solus-project.budgie-panel.panel"] == nil {
+		t.Errorf("Failed to load compiled schemas: %v", loadErr)
+	}
+
+	root := t.TempDir()
+	dir := filepath.Join(root, GSchemaInstallDir)
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "org.example.gschema.xml"), GSchemaXML, 0644)
+
+	if set, loadErr = LoadInstalledGSchemas(root); loadErr != nil || set.Schemas["org.example"] == nil || set.Enums["org.example.Mode"] == nil {
+		t.Errorf("Failed to load schema files: %v", loadErr)
+	}
+}
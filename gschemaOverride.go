@@ -0,0 +1,130 @@
+/* gschemaOverride.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// gschema.override files are keyfiles, like a dconf dump, but with a section per GSettings schema ID rather than per path
+// glib-compile-schemas applies them over the defaults of the gschema.xml files, so distributions can change defaults
+// We read them into a Schema with a section per schema ID, so String and WriteTo write them back out
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ParseGSchemaOverride will parse the content of a gschema.override file into a Schema with a section per schema ID
+// Keys appearing more than once take the last value, as with GKeyFile. Per-desktop keys, like key[GNOME], are not
+// supported by our keyfile parser and are skipped, with a warning in the Schema's Warnings
+func ParseGSchemaOverride(content []byte) (*Schema, error) {
+	return NewSchemaWithOptions("", content, ParseOptions{Duplicates: DuplicatePolicyLastWins})
+}
+
+// LoadGSchemaOverrides will parse every gschema.override file in the directory, like /usr/share/glib-2.0/schemas, into one Schema
+// Files are applied in the order of their names, as with glib-compile-schemas, so 20_vendor.gschema.override takes priority
+// over 10_distro.gschema.override
+func LoadGSchemaOverrides(dir string) (overrides *Schema, loadErr error) {
+	var files []string
+	if files, loadErr = filepath.Glob(filepath.Join(dir, "*.gschema.override")); loadErr != nil {
+		return
+	}
+
+	sort.Strings(files)
+	parsed := make([]*Schema, 0, len(files))
+
+	for _, file := range files {
+		content, readErr := os.ReadFile(file)
+
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		if len(content) == 0 { // Nothing to override
+			continue
+		}
+
+		fileOverrides, parseErr := ParseGSchemaOverride(content)
+
+		if parseErr != nil {
+			return nil, fmt.Errorf("%s: %w", file, parseErr)
+		}
+
+		parsed = append(parsed, fileOverrides)
+	}
+
+	return MergeGSchemaOverrides(parsed...), nil
+}
+
+// MergeGSchemaOverrides will merge the overrides into a new Schema, in order of increasing priority
+// Where more than one sets the same key of the same schema, the value of the last one is used
+func MergeGSchemaOverrides(overrides ...*Schema) *Schema {
+	merged := &Schema{
+		Map:   make(map[string]*SchemaKV),
+		Order: []string{},
+	}
+
+	for _, override := range overrides {
+		for _, id := range override.sortedSections() {
+			if !merged.HasSection(id) {
+				merged.AddSection(id, &SchemaKV{Order: []string{}, Keys: make(map[string]*SchemaType)})
+			}
+
+			kv := merged.Map[id]
+
+			for _, key := range override.Map[id].sortedKeys() {
+				kv.DeleteKeys(key) // Replace any lower priority value
+				kv.AddKey(key, override.Map[id].Keys[key].Duplicate())
+			}
+		}
+	}
+
+	return merged
+}
+
+// NewGSchemaOverride will convert the Schema, with a section per dconf path, into overrides with a section per schema ID
+// Sections are mapped to GSettings schemas with a SchemaMapper. Keys that can not be overridden are returned as skipped: those in
+// sections with no schema or of unknown keys, and those of relocatable schemas, as an override would change every instance
+func NewGSchemaOverride(schema *Schema, source SchemaSource) (overrides *Schema, skipped []SelectedKey) {
+	overrides = &Schema{
+		Map:   make(map[string]*SchemaKV),
+		Order: []string{},
+	}
+
+	skipped = []SelectedKey{}
+	mapper := NewSchemaMapper(source)
+
+	for _, section := range schema.sortedSections() {
+		gschema := source.Schema(mapper.SchemaID(schema.KeyPath(section, "")))
+		kv := schema.Map[section]
+
+		for _, key := range kv.sortedKeys() {
+			if gschema == nil || gschema.Relocatable() || gschema.Keys[key] == nil {
+				skipped = append(skipped, SelectedKey{Key: key, Section: section})
+				continue
+			}
+
+			if !overrides.HasSection(gschema.ID) {
+				overrides.AddSection(gschema.ID, &SchemaKV{Order: []string{}, Keys: make(map[string]*SchemaType)})
+			}
+
+			overrides.Map[gschema.ID].AddKey(key, kv.Keys[key].Duplicate())
+		}
+	}
+
+	return
+}
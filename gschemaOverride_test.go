@@ -0,0 +1,90 @@
+/* gschemaOverride_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestLoadGSchemaOverrides will test that override files are applied in the order of their names
+func TestLoadGSchemaOverrides(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"10_distro.gschema.override": "[com.solus-project.budgie-panel]\ndark-theme=false\nlayout='solus'\n\n[com.solus-project.tray]\nspacing=4\n",
+		"20_vendor.gschema.override": "# Vendor changes\n[com.solus-project.budgie-panel]\nlayout='vendor'\nlayout='vendor-again'\n",
+		"30_empty.gschema.override":  "",
+		"unrelated.txt":              "[com.solus-project.tray]\nspacing=8\n",
+	}
+
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+
+	overrides, loadErr := LoadGSchemaOverrides(dir)
+
+	if loadErr != nil {
+		t.Fatalf("Failed to load: %s", loadErr)
+	}
+
+	expected := "[com.solus-project.budgie-panel]\ndark-theme=false\nlayout='vendor-again'\n\n[com.solus-project.tray]\nspacing=4\n"
+
+	if overrides.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, overrides.String())
+	}
+
+	if overrides, _ = LoadGSchemaOverrides(t.TempDir()); len(overrides.Map) != 0 {
+		t.Errorf("Expected no overrides from an empty directory, got %v", overrides.Map)
+	}
+}
+
+// TestNewGSchemaOverride will test converting our example into overrides, which only covers keys of fixed schemas
+func TestNewGSchemaOverride(t *testing.T) {
+	set := NewBudgieGSchemaSet(t)
+	schema := NewTestSchema(t)
+	schema.Set("removed-key", &SchemaType{Type: "bool", BoolVal: true, Val: "true"})
+
+	overrides, skipped := NewGSchemaOverride(schema, set)
+
+	expected := "[com.solus-project.budgie-panel]\ndark-theme=true\nlayout='solus-fortitude'\nmigration-level=1\npanels=['8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c']\n"
+
+	if overrides.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, overrides.String())
+	}
+
+	if skipped[0] != (SelectedKey{Key: "removed-key", Section: "/"}) {
+		t.Errorf("Expected removed-key to be skipped first, got %+v", skipped[0])
+	}
+
+	var keys int
+	for _, kv := range schema.Map {
+		keys += len(kv.Keys)
+	}
+
+	if len(skipped)+len(overrides.Map["com.solus-project.budgie-panel"].Keys) != keys {
+		t.Errorf("Expected every key to be converted or skipped, got %d skipped", len(skipped))
+	}
+
+	parsed, parseErr := ParseGSchemaOverride([]byte(overrides.String()))
+
+	if parseErr != nil || !reflect.DeepEqual(parsed.sortedSections(), overrides.sortedSections()) || !parsed.Map["com.solus-project.budgie-panel"].Equal(overrides.Map["com.solus-project.budgie-panel"]) {
+		t.Errorf("Expected the overrides to parse back the same, got %v", parseErr)
+	}
+}
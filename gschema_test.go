@@ -0,0 +1,194 @@
+/* gschema_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var GSchemaXML = []byte(`<schemalist gettext-domain="example">
+  <enum id="org.example.Mode">
+    <value nick="off" value="0"/>
+    <value nick="on" value="1"/>
+  </enum>
+  <flags id="org.example.Features">
+    <value nick="sound" value="1"/>
+    <value nick="video" value="0x2"/>
+  </flags>
+  <schema id="org.example.base">
+    <key name="mode" enum="org.example.Mode">
+      <default>'off'</default>
+    </key>
+    <key type="s" name="color">
+      <choices>
+        <choice value="red"/>
+        <choice value="blue"/>
+      </choices>
+      <aliases>
+        <alias value="crimson" target="red"/>
+      </aliases>
+      <default>'red'</default>
+      <summary>
+        Color
+      </summary>
+      <description>
+        The color of
+        the thing
+      </description>
+    </key>
+  </schema>
+  <schema id="org.example" path="/org/example/" extends="org.example.base">
+    <override name="color">'blue'</override>
+    <key name="features" flags="org.example.Features">
+      <default>['sound']</default>
+    </key>
+    <key type="d" name="volume">
+      <range min="0.0" max="1.0"/>
+      <default>0.5</default>
+    </key>
+    <child name="window" schema="org.example.window"/>
+  </schema>
+  <schema id="org.example.window">
+    <key type="(ii)" name="size">
+      <default>(640, 480)</default>
+    </key>
+  </schema>
+</schemalist>
+`)
+
+// TestParseGSchemaXML will test parsing a gschema.xml file covering everything we support
+func TestParseGSchemaXML(t *testing.T) {
+	set, parseErr := ParseGSchemaXML(GSchemaXML)
+
+	if parseErr != nil {
+		t.Fatalf("Failed to parse: %s", parseErr)
+	}
+
+	if strings.Join(set.IDs(), ",") != "org.example,org.example.base,org.example.window" {
+		t.Errorf("Unexpected schemas: %v", set.IDs())
+	}
+
+	base := set.Schemas["org.example.base"]
+	color := base.Keys["color"]
+
+	if !base.Relocatable() || base.GettextDomain != "example" {
+		t.Errorf("Unexpected base schema: %+v", base)
+	}
+
+	if color.Summary != "Color" || color.Description != "The color of the thing" || strings.Join(color.Choices, ",") != "red,blue" || color.Aliases["crimson"] != "red" {
+		t.Errorf("Unexpected color key: %+v", color)
+	}
+
+	if mode := base.Keys["mode"]; mode.Type != "s" || mode.Enum != "org.example.Mode" || mode.Default != "'off'" {
+		t.Errorf("Unexpected mode key: %+v", mode)
+	}
+
+	example := set.Schemas["org.example"]
+
+	if example.Relocatable() || example.Path != "/org/example/" {
+		t.Errorf("Unexpected path: %s", example.Path)
+	}
+
+	if example.Keys["color"].Default != "'blue'" || base.Keys["color"].Default != "'red'" || example.Keys["mode"] == nil { // Overridden and inherited keys
+		t.Errorf("Failed to resolve extends: %v", example.Keys)
+	}
+
+	if features := example.Keys["features"]; features.Type != "as" || features.Flags != "org.example.Features" {
+		t.Errorf("Unexpected features key: %+v", features)
+	}
+
+	if volume := example.Keys["volume"]; volume.Range == nil || volume.Range.Min != "0.0" || volume.Range.Max != "1.0" {
+		t.Errorf("Unexpected volume range: %+v", volume.Range)
+	}
+
+	if len(example.Children) != 1 || example.Children[0] != (GSchemaChild{Name: "window", Schema: "org.example.window"}) {
+		t.Errorf("Unexpected children: %v", example.Children)
+	}
+
+	if features := set.Enums["org.example.Features"]; !features.Flags || features.Values[1].Value != 2 {
+		t.Errorf("Unexpected flags: %+v", features)
+	}
+
+	if mode := set.Enums["org.example.Mode"]; mode.Flags || len(mode.Values) != 2 {
+		t.Errorf("Unexpected enum: %+v", mode)
+	}
+}
+
+// TestParseGSchemaXMLInvalid will test that invalid schemas are rejected
+func TestParseGSchemaXMLInvalid(t *testing.T) {
+	tests := []string{
+		`<schemalist><schema path="/a/"/></schemalist>`,
+		`<schemalist><schema id="a" path="/a"/></schemalist>`,
+		`<schemalist><schema id="a"><key name="k"><default>1</default></key></schema></schemalist>`,
+		`<schemalist><schema id="a"><key name="k" type="a"><default>[]</default></key></schema></schemalist>`,
+		`<schemalist><schema id="a"><key name="k" type="i"/></schema></schemalist>`,
+		`<schemalist><enum id="e"><value nick="x" value="y"/></enum></schemalist>`,
+		`<schemalist><schema id="a"><override name="k">1</override></schema></schemalist>`,
+		`<schemalist`,
+	}
+
+	for _, content := range tests {
+		if _, parseErr := ParseGSchemaXML([]byte(content)); !errors.Is(parseErr, ErrGSchemaInvalid) {
+			t.Errorf("Expected ErrGSchemaInvalid for %s, got %v instead.", content, parseErr)
+		}
+	}
+
+	if _, parseErr := ParseGSchemaXML([]byte(`<schemalist><schema id="a"/><schema id="a"/></schemalist>`)); !errors.Is(parseErr, ErrGSchemaDuplicate) {
+		t.Errorf("Expected ErrGSchemaDuplicate, got %v instead.", parseErr)
+	}
+}
+
+// TestLoadGSchemaDir will test loading our example schemas
+func TestLoadGSchemaDir(t *testing.T) {
+	set, loadErr := LoadGSchemaDir("examples/schemas")
+
+	if loadErr != nil {
+		t.Fatalf("Failed to load: %s", loadErr)
+	}
+
+	if panel := set.Schemas["com.solus-project.budgie-panel.panel"]; panel == nil || !panel.Relocatable() || panel.Keys["size"].Range.Max != "200" {
+		t.Errorf("Unexpected panel schema: %+v", panel)
+	}
+
+	other, _ := ParseGSchemaXML(GSchemaXML)
+
+	if mergeErr := set.Merge(other); mergeErr != nil || set.Schemas["org.example"] == nil {
+		t.Errorf("Failed to merge: %v", mergeErr)
+	}
+
+	if mergeErr := set.Merge(other); !errors.Is(mergeErr, ErrGSchemaDuplicate) {
+		t.Errorf("Expected ErrGSchemaDuplicate merging twice, got %v instead.", mergeErr)
+	}
+}
+
+// TestValidGVariantType will test ValidGVariantType
+func TestValidGVariantType(t *testing.T) {
+	for _, typeString := range []string{"b", "as", "a{sv}", "(ii)", "a(ss)", "mi", "()", "aa{sas}"} {
+		if !ValidGVariantType(typeString) {
+			t.Errorf("Expected %s to be valid", typeString)
+		}
+	}
+
+	for _, typeString := range []string{"", "a", "ii", "(i", "{sv}x", "a{vs}", "z"} {
+		if ValidGVariantType(typeString) {
+			t.Errorf("Expected %s to be invalid", typeString)
+		}
+	}
+}
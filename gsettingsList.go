@@ -0,0 +1,104 @@
+/* gsettingsList.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// gsettings list-recursively prints the effective value of every key, defaults included, as lines of schema ID, key and value
+// Unlike a dconf dump there are no paths, so we need GSettings schemas to put keys back where dconf keeps them
+
+import (
+	"strings"
+)
+
+// ParseGSettingsList will parse the output of gsettings list-recursively into a Schema at /, with the schema ID of each section in SchemaIDs
+// Sections are at the path of their schema, found with the mapper. Schemas the mapper has no fixed path for, or every
+// schema if the mapper is nil, get a section named after their ID instead, like org.gnome.desktop.interface
+// Keys of a schema at more than one path, like a relocatable schema used as a child, are added at each of its paths
+func ParseGSettingsList(content []byte, mapper *SchemaMapper) (schema *Schema, parseErr error) {
+	if len(content) == 0 { // content not specified or has no content
+		parseErr = ErrNoContentProvided
+		return
+	}
+
+	schema = &Schema{
+		Map:       make(map[string]*SchemaKV),
+		Order:     []string{},
+		Path:      "/",
+		SchemaIDs: make(map[string]string),
+	}
+
+	for index, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 3)
+
+		if len(fields) != 3 || fields[0] == "" || fields[1] == "" {
+			return nil, &ParseError{Column: 1, Err: ErrParseNotKeyValue, Line: index + 1}
+		}
+
+		id, key := fields[0], fields[1]
+		value, valueErr := NewSchemaType(strings.TrimSpace(fields[2]))
+
+		if valueErr != nil {
+			return nil, &ParseError{Column: len(id) + len(key) + 3, Err: ErrParseInvalidValue, Key: key, Line: index + 1}
+		}
+
+		for _, section := range gsettingsListSections(id, mapper) {
+			if !schema.HasSection(section) {
+				if addErr := schema.AddSection(section, &SchemaKV{Order: []string{}, Keys: make(map[string]*SchemaType)}); addErr != nil {
+					return nil, &ParseError{Column: 1, Err: addErr, Line: index + 1}
+				}
+
+				schema.SchemaIDs[section] = id
+			}
+
+			kv := schema.Map[section]
+			kv.DeleteKeys(key) // Should not be listed twice, but the last value wins if it is
+
+			if addErr := kv.AddKey(key, value.Duplicate()); addErr != nil {
+				return nil, &ParseError{Column: len(id) + 2, Err: addErr, Key: key, Line: index + 1}
+			}
+		}
+	}
+
+	return
+}
+
+// gsettingsListSections will return the sections of the keys of the schema, at its fixed paths or named after its ID
+func gsettingsListSections(id string, mapper *SchemaMapper) (sections []string) {
+	if mapper != nil {
+		for _, mapping := range mapper.Paths(id) {
+			if mapping.Path == "" { // Mounted, so we do not know which instance
+				continue
+			}
+
+			if section := TrimSectionSlashes(mapping.Path); section != "" {
+				sections = append(sections, section)
+			} else {
+				sections = append(sections, "/")
+			}
+		}
+	}
+
+	if len(sections) == 0 {
+		sections = []string{id}
+	}
+
+	return
+}
@@ -0,0 +1,108 @@
+/* gsettingsList_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// GSettingsList is the output of gsettings list-recursively for our example schemas and GSchemaXML
+var GSettingsList = []byte(`com.solus-project.budgie-panel dark-theme true
+com.solus-project.budgie-panel layout 'default'
+com.solus-project.budgie-panel migration-level uint32 0
+com.solus-project.budgie-panel panels @as []
+org.example color 'blue'
+org.example features ['sound']
+org.example mode 'off'
+org.example volume 0.5
+org.example.window size (640, 480)
+`)
+
+// TestParseGSettingsList will test parsing gsettings list-recursively output with a mapper, putting keys at their paths
+func TestParseGSettingsList(t *testing.T) {
+	set := NewBudgieGSchemaSet(t)
+	example, _ := ParseGSchemaXML(GSchemaXML)
+	set.Merge(example)
+
+	schema, parseErr := ParseGSettingsList(GSettingsList, NewSchemaMapper(set))
+
+	if parseErr != nil {
+		t.Fatalf("Failed to parse: %s", parseErr)
+	}
+
+	expectedIDs := map[string]string{
+		"com/solus-project/budgie-panel": "com.solus-project.budgie-panel",
+		"org/example":                    "org.example",
+		"org/example/window":             "org.example.window",
+	}
+
+	if !reflect.DeepEqual(schema.SchemaIDs, expectedIDs) {
+		t.Errorf("Expected schema IDs %v, got %v instead.", expectedIDs, schema.SchemaIDs)
+	}
+
+	if level, _ := schema.Get("/com/solus-project/budgie-panel/migration-level"); level == nil || level.Type != "uint32" {
+		t.Errorf("Unexpected migration-level: %+v", level)
+	}
+
+	if report := Validate(schema, set); !report.Valid() {
+		t.Errorf("Expected the parsed Schema to be valid, got %+v", report.Issues)
+	}
+
+	if _, report := PruneDefaults(schema, set, nil); len(report.Pruned) != 9 {
+		t.Errorf("Expected every key to be pruned as a default, got %+v", report.Pruned)
+	}
+
+	if clone := schema.Clone(); !reflect.DeepEqual(clone.SchemaIDs, schema.SchemaIDs) {
+		t.Errorf("Expected Clone to copy SchemaIDs, got %v", clone.SchemaIDs)
+	}
+
+	schema.DeleteSections("org/example/window")
+
+	if _, exists := schema.SchemaIDs["org/example/window"]; exists {
+		t.Error("Expected deleting a section to delete its schema ID")
+	}
+}
+
+// TestParseGSettingsListUnmapped will test that schemas without a path are kept as sections named by their ID
+func TestParseGSettingsListUnmapped(t *testing.T) {
+	schema, parseErr := ParseGSettingsList(GSettingsList, nil)
+
+	if parseErr != nil {
+		t.Fatalf("Failed to parse: %s", parseErr)
+	}
+
+	if sections := schema.sortedSections(); !reflect.DeepEqual(sections, []string{"com.solus-project.budgie-panel", "org.example", "org.example.window"}) {
+		t.Errorf("Unexpected sections: %v", sections)
+	}
+
+	if schema.SchemaIDs["org.example"] != "org.example" || schema.Map["org.example"].Keys["volume"].FloatVal != 0.5 {
+		t.Errorf("Unexpected org.example section: %+v", schema.Map["org.example"])
+	}
+
+	var parseError *ParseError
+
+	if _, parseErr = ParseGSettingsList([]byte("org.example color 'blue'\norg.example broken\n"), nil); !errors.As(parseErr, &parseError) || parseError.Line != 2 || !errors.Is(parseErr, ErrParseNotKeyValue) {
+		t.Errorf("Expected a ParseError on line 2, got %v instead.", parseErr)
+	}
+
+	if _, parseErr = ParseGSettingsList(nil, nil); !errors.Is(parseErr, ErrNoContentProvided) {
+		t.Errorf("Expected ErrNoContentProvided, got %v instead.", parseErr)
+	}
+}
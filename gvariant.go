@@ -0,0 +1,200 @@
+/* gvariant.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// dconf stores values in the GVariant text format, which our SchemaType keeps as Val for anything other than bools and numbers
+// These helpers cover the parts of that format we need to look inside of values, like strings and arrays
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// QuoteString will quote the string in the GVariant text format, in single quotes unless it contains one and no double quotes
+func QuoteString(str string) string {
+	quote := byte('\'')
+
+	if strings.ContainsRune(str, '\'') && !strings.ContainsRune(str, '"') { // Same preference as g_variant_print
+		quote = '"'
+	}
+
+	var quoted strings.Builder
+	quoted.WriteByte(quote)
+
+	for _, char := range str {
+		switch char {
+		case rune(quote), '\\':
+			quoted.WriteRune('\\')
+			quoted.WriteRune(char)
+		case '\a':
+			quoted.WriteString(`\a`)
+		case '\b':
+			quoted.WriteString(`\b`)
+		case '\f':
+			quoted.WriteString(`\f`)
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		case '\v':
+			quoted.WriteString(`\v`)
+		default:
+			if char < 0x20 || char == 0x7f { // Other control characters
+				quoted.WriteString(fmt.Sprintf(`\u%04x`, char))
+			} else {
+				quoted.WriteRune(char)
+			}
+		}
+	}
+
+	quoted.WriteByte(quote)
+	return quoted.String()
+}
+
+// UnquoteString will decode a single or double quoted string in the GVariant text format
+// A leading type annotation or @s is not part of a string, so should be removed beforehand
+func UnquoteString(raw string) (str string, unquoteErr error) {
+	if len(raw) < 2 || (raw[0] != '\'' && raw[0] != '"') || raw[len(raw)-1] != raw[0] {
+		unquoteErr = fmt.Errorf("%w: not a quoted string: %s", ErrParseInvalidValue, raw)
+		return
+	}
+
+	quote := raw[0]
+	body := raw[1 : len(raw)-1]
+	var unquoted strings.Builder
+
+	for index := 0; index < len(body); index++ {
+		char := body[index]
+
+		if char == quote { // Unescaped quote in the middle, so this is more than one string
+			unquoteErr = fmt.Errorf("%w: unescaped quote in string: %s", ErrParseInvalidValue, raw)
+			return
+		}
+
+		if char != '\\' {
+			unquoted.WriteByte(char)
+			continue
+		}
+
+		if index++; index == len(body) { // Escapes the closing quote
+			unquoteErr = fmt.Errorf("%w: unterminated string: %s", ErrParseInvalidValue, raw)
+			return
+		}
+
+		switch escaped := body[index]; escaped {
+		case 'a':
+			unquoted.WriteByte('\a')
+		case 'b':
+			unquoted.WriteByte('\b')
+		case 'f':
+			unquoted.WriteByte('\f')
+		case 'n':
+			unquoted.WriteByte('\n')
+		case 'r':
+			unquoted.WriteByte('\r')
+		case 't':
+			unquoted.WriteByte('\t')
+		case 'v':
+			unquoted.WriteByte('\v')
+		case 'u', 'U':
+			digits := 4
+			if escaped == 'U' {
+				digits = 8
+			}
+
+			if index+digits >= len(body) { // Not enough digits
+				unquoteErr = fmt.Errorf("%w: short unicode escape in string: %s", ErrParseInvalidValue, raw)
+				return
+			}
+
+			code, parseErr := strconv.ParseUint(body[index+1:index+1+digits], 16, 32)
+
+			if parseErr != nil || !utf8.ValidRune(rune(code)) {
+				unquoteErr = fmt.Errorf("%w: invalid unicode escape in string: %s", ErrParseInvalidValue, raw)
+				return
+			}
+
+			unquoted.WriteRune(rune(code))
+			index += digits
+		default: // Anything else, including quotes and backslashes, is itself
+			unquoted.WriteByte(escaped)
+		}
+	}
+
+	return unquoted.String(), nil
+}
+
+// SplitArray will split an array in the GVariant text format, like ['a', 'b'], into the text of each item
+// Items are not decoded, so strings keep their quotes. An @as or similar type annotation before the [ is skipped
+func SplitArray(raw string) (items []string, splitErr error) {
+	raw = strings.TrimSpace(raw)
+
+	if strings.HasPrefix(raw, "@") { // Type annotation, like @as []
+		if space := strings.IndexByte(raw, ' '); space != -1 {
+			raw = strings.TrimSpace(raw[space:])
+		}
+	}
+
+	if len(raw) < 2 || raw[0] != '[' || raw[len(raw)-1] != ']' {
+		splitErr = fmt.Errorf("%w: not an array: %s", ErrParseInvalidValue, raw)
+		return
+	}
+
+	items = []string{}
+	body := raw[1 : len(raw)-1]
+	depth := 0       // Depth of nested arrays, tuples and dictionaries
+	quote := byte(0) // Quote of the string we are in, if any
+	start := 0
+
+	for index := 0; index < len(body); index++ {
+		char := body[index]
+
+		switch {
+		case quote != 0: // In a string
+			if char == '\\' {
+				index++
+			} else if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"':
+			quote = char
+		case char == '[' || char == '(' || char == '{' || char == '<':
+			depth++
+		case char == ']' || char == ')' || char == '}' || char == '>':
+			depth--
+		case char == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(body[start:index]))
+			start = index + 1
+		}
+	}
+
+	if quote != 0 || depth != 0 {
+		splitErr = fmt.Errorf("%w: unbalanced array: %s", ErrParseInvalidValue, raw)
+		return nil, splitErr
+	}
+
+	if last := strings.TrimSpace(body[start:]); last != "" || len(items) != 0 {
+		items = append(items, last)
+	}
+
+	return
+}
@@ -0,0 +1,384 @@
+/* gvariantBinary.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// GVariant values are stored in compiled files (like gschemas.compiled) in the GVariant serialisation format
+// This decodes that format, so we can read values back into the GVariant text format used everywhere else
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// gvariantType is a parsed GVariant type string, along with how values of it are laid out when serialised
+type gvariantType struct {
+	alignment int             // Alignment of values, in bytes
+	elements  []*gvariantType // Element of an array or maybe, or members of a tuple or dictionary entry
+	fixedSize int             // Size of every value, or 0 if values vary in size
+	kind      byte            // First character of the type string, like a for arrays or ( for tuples
+	str       string          // Type string
+}
+
+// gvariantValue is a serialised GVariant value
+type gvariantValue struct {
+	data  []byte
+	order binary.ByteOrder
+	typ   *gvariantType
+}
+
+// parseGVariantType will parse the GVariant type string, like a{sv}
+func parseGVariantType(typeString string) (*gvariantType, error) {
+	if !ValidGVariantType(typeString) || strings.ContainsAny(typeString, "*?r") { // Only definite types can be serialised
+		return nil, fmt.Errorf("%w: invalid gvariant type %q", ErrGVDBInvalid, typeString)
+	}
+
+	typ, _ := scanSerialisedType(typeString, 0)
+	return typ, nil
+}
+
+// scanSerialisedType will parse the single complete, valid type starting at the index, returning the index after it
+func scanSerialisedType(typeString string, index int) (*gvariantType, int) {
+	typ := &gvariantType{kind: typeString[index]}
+	start := index
+	index++
+
+	switch typ.kind {
+	case 'b', 'y':
+		typ.alignment, typ.fixedSize = 1, 1
+	case 'n', 'q':
+		typ.alignment, typ.fixedSize = 2, 2
+	case 'i', 'u', 'h':
+		typ.alignment, typ.fixedSize = 4, 4
+	case 'x', 't', 'd':
+		typ.alignment, typ.fixedSize = 8, 8
+	case 's', 'o', 'g':
+		typ.alignment = 1
+	case 'v':
+		typ.alignment = 8
+	case 'a', 'm':
+		var element *gvariantType
+		element, index = scanSerialisedType(typeString, index)
+		typ.elements = []*gvariantType{element}
+		typ.alignment = element.alignment
+	case '(', '{':
+		typ.alignment = 1
+		fixed := true
+		size := 0
+
+		for typeString[index] != ')' && typeString[index] != '}' {
+			var member *gvariantType
+			member, index = scanSerialisedType(typeString, index)
+			typ.elements = append(typ.elements, member)
+
+			if member.alignment > typ.alignment {
+				typ.alignment = member.alignment
+			}
+
+			if member.fixedSize == 0 {
+				fixed = false
+			} else {
+				size = alignTo(size, member.alignment) + member.fixedSize
+			}
+		}
+
+		index++ // Closing ) or }
+
+		if fixed {
+			if typ.fixedSize = alignTo(size, typ.alignment); typ.fixedSize == 0 { // The unit type still takes a byte
+				typ.fixedSize = 1
+			}
+		}
+	}
+
+	typ.str = typeString[start:index]
+	return typ, index
+}
+
+// children will return the child values of a container value: the items of an array, the members of a tuple or
+// dictionary entry, the value of a maybe (if any) or the value within a variant
+func (value gvariantValue) children() (children []gvariantValue, decodeErr error) {
+	data := value.data
+
+	switch value.typ.kind {
+	case 'v':
+		separator := strings.LastIndexByte(string(data), 0)
+
+		if separator == -1 {
+			return nil, fmt.Errorf("%w: variant without a type", ErrGVDBInvalid)
+		}
+
+		inner, typeErr := parseGVariantType(string(data[separator+1:]))
+
+		if typeErr != nil {
+			return nil, typeErr
+		}
+
+		return []gvariantValue{{data: data[:separator], order: value.order, typ: inner}}, nil
+	case 'm':
+		element := value.typ.elements[0]
+
+		if len(data) == 0 { // Nothing
+			return []gvariantValue{}, nil
+		}
+
+		if element.fixedSize == 0 { // Variable size values are followed by a zero byte
+			data = data[:len(data)-1]
+		} else if len(data) != element.fixedSize {
+			return nil, fmt.Errorf("%w: maybe of %s has the wrong size", ErrGVDBInvalid, element.str)
+		}
+
+		return []gvariantValue{{data: data, order: value.order, typ: element}}, nil
+	case 'a':
+		return value.arrayChildren()
+	case '(', '{':
+		return value.tupleChildren()
+	}
+
+	return nil, fmt.Errorf("%w: %s is not a container", ErrGVDBInvalid, value.typ.str)
+}
+
+// arrayChildren will return the items of an array value
+func (value gvariantValue) arrayChildren() (children []gvariantValue, decodeErr error) {
+	data := value.data
+	element := value.typ.elements[0]
+	children = []gvariantValue{}
+
+	if element.fixedSize != 0 { // Items are laid out one after the other
+		if len(data)%element.fixedSize != 0 {
+			return nil, fmt.Errorf("%w: array of %s has the wrong size", ErrGVDBInvalid, element.str)
+		}
+
+		for start := 0; start < len(data); start += element.fixedSize {
+			children = append(children, gvariantValue{data: data[start : start+element.fixedSize], order: value.order, typ: element})
+		}
+
+		return
+	}
+
+	if len(data) == 0 { // Empty
+		return
+	}
+
+	offsetSize := gvariantOffsetSize(len(data)) // The end of each item is stored at the end of the array
+	framesStart := value.readOffset(data[len(data)-offsetSize:])
+
+	if framesStart > len(data) || (len(data)-framesStart)%offsetSize != 0 {
+		return nil, fmt.Errorf("%w: array of %s has invalid offsets", ErrGVDBInvalid, element.str)
+	}
+
+	start := 0
+
+	for frame := framesStart; frame < len(data); frame += offsetSize {
+		end := value.readOffset(data[frame : frame+offsetSize])
+		start = alignTo(start, element.alignment)
+
+		if start > end || end > framesStart {
+			return nil, fmt.Errorf("%w: array of %s has invalid offsets", ErrGVDBInvalid, element.str)
+		}
+
+		children = append(children, gvariantValue{data: data[start:end], order: value.order, typ: element})
+		start = end
+	}
+
+	return
+}
+
+// tupleChildren will return the members of a tuple or dictionary entry value
+func (value gvariantValue) tupleChildren() (children []gvariantValue, decodeErr error) {
+	data := value.data
+	offsetSize := gvariantOffsetSize(len(data))
+	framesEnd := len(data) // The end of each variable size member, other than the last, is stored at the end in reverse
+	start := 0
+	children = []gvariantValue{}
+
+	for index, member := range value.typ.elements {
+		start = alignTo(start, member.alignment)
+		end := 0
+
+		switch {
+		case member.fixedSize != 0:
+			end = start + member.fixedSize
+		case index == len(value.typ.elements)-1: // Last member runs up to our offsets
+			end = framesEnd
+		default:
+			if framesEnd -= offsetSize; framesEnd < 0 {
+				return nil, fmt.Errorf("%w: tuple %s has invalid offsets", ErrGVDBInvalid, value.typ.str)
+			}
+
+			end = value.readOffset(data[framesEnd : framesEnd+offsetSize])
+		}
+
+		if start > end || end > len(data) {
+			return nil, fmt.Errorf("%w: tuple %s has invalid offsets", ErrGVDBInvalid, value.typ.str)
+		}
+
+		children = append(children, gvariantValue{data: data[start:end], order: value.order, typ: member})
+		start = end
+	}
+
+	return
+}
+
+// text will print the value in the GVariant text format, without type annotations, like the defaults of a gschema.xml file
+func (value gvariantValue) text() (string, error) {
+	data := value.data
+	typ := value.typ
+
+	if typ.fixedSize != 0 && len(data) != typ.fixedSize && typ.kind != '(' && typ.kind != '{' {
+		return "", fmt.Errorf("%w: %s has the wrong size", ErrGVDBInvalid, typ.str)
+	}
+
+	switch typ.kind {
+	case 'b':
+		return strconv.FormatBool(data[0] != 0), nil
+	case 'y':
+		return fmt.Sprintf("0x%02x", data[0]), nil
+	case 'n':
+		return strconv.FormatInt(int64(int16(value.order.Uint16(data))), 10), nil
+	case 'q':
+		return strconv.FormatUint(uint64(value.order.Uint16(data)), 10), nil
+	case 'i', 'h':
+		return strconv.FormatInt(int64(int32(value.order.Uint32(data))), 10), nil
+	case 'u':
+		return strconv.FormatUint(uint64(value.order.Uint32(data)), 10), nil
+	case 'x':
+		return strconv.FormatInt(int64(value.order.Uint64(data)), 10), nil
+	case 't':
+		return strconv.FormatUint(value.order.Uint64(data), 10), nil
+	case 'd':
+		return formatGVariantDouble(math.Float64frombits(value.order.Uint64(data))), nil
+	case 's', 'o', 'g':
+		str, strErr := value.str()
+		return QuoteString(str), strErr
+	}
+
+	children, childErr := value.children()
+
+	if childErr != nil {
+		return "", childErr
+	}
+
+	texts := make([]string, 0, len(children))
+
+	for _, child := range children {
+		text, textErr := child.text()
+
+		if textErr != nil {
+			return "", textErr
+		}
+
+		texts = append(texts, text)
+	}
+
+	switch typ.kind {
+	case 'v':
+		return "<" + texts[0] + ">", nil
+	case 'm':
+		if len(texts) == 0 {
+			return "nothing", nil
+		}
+
+		if typ.elements[0].kind == 'm' { // Nested maybes need to be explicit
+			return "just " + texts[0], nil
+		}
+
+		return texts[0], nil
+	case 'a':
+		if typ.elements[0].kind == '{' { // Dictionary, printed as {key: value, ...}
+			for index, child := range children {
+				entry, entryErr := child.children()
+
+				if entryErr != nil || len(entry) != 2 {
+					return "", fmt.Errorf("%w: invalid dictionary entry in %s", ErrGVDBInvalid, typ.str)
+				}
+
+				key, _ := entry[0].text() // Already printed successfully as part of the entry
+				val, _ := entry[1].text()
+				texts[index] = key + ": " + val
+			}
+
+			return "{" + strings.Join(texts, ", ") + "}", nil
+		}
+
+		return "[" + strings.Join(texts, ", ") + "]", nil
+	case '{':
+		return "{" + strings.Join(texts, ", ") + "}", nil
+	default: // Tuple
+		if len(texts) == 1 {
+			return "(" + texts[0] + ",)", nil
+		}
+
+		return "(" + strings.Join(texts, ", ") + ")", nil
+	}
+}
+
+// str will return the value of a string, object path or signature
+func (value gvariantValue) str() (string, error) {
+	if len(value.data) == 0 || value.data[len(value.data)-1] != 0 {
+		return "", fmt.Errorf("%w: string is not nul terminated", ErrGVDBInvalid)
+	}
+
+	return string(value.data[:len(value.data)-1]), nil
+}
+
+// readOffset will read a framing offset of the size of the provided bytes
+func (value gvariantValue) readOffset(data []byte) int {
+	switch len(data) {
+	case 1:
+		return int(data[0])
+	case 2:
+		return int(value.order.Uint16(data))
+	case 4:
+		return int(value.order.Uint32(data))
+	default:
+		return int(value.order.Uint64(data))
+	}
+}
+
+// gvariantOffsetSize will return the size of the framing offsets of a container of the provided size
+func gvariantOffsetSize(size int) int {
+	switch {
+	case size <= math.MaxUint8:
+		return 1
+	case size <= math.MaxUint16:
+		return 2
+	case uint64(size) <= math.MaxUint32:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// formatGVariantDouble will format the double like g_variant_print, always with a decimal point or exponent
+func formatGVariantDouble(double float64) string {
+	text := strconv.FormatFloat(double, 'g', -1, 64)
+
+	if !strings.ContainsAny(text, ".eEnN") { // Not already clearly a double (nor inf or nan)
+		text += ".0"
+	}
+
+	return text
+}
+
+// alignTo will round the offset up to the alignment
+func alignTo(offset int, alignment int) int {
+	return (offset + alignment - 1) / alignment * alignment
+}
@@ -0,0 +1,93 @@
+/* gvariantBinary_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// TestGVariantValueText will test printing serialised values in the GVariant text format
+func TestGVariantValueText(t *testing.T) {
+	tests := []struct {
+		typ  string
+		data []byte
+		text string
+	}{
+		{"b", []byte{1}, "true"},
+		{"y", []byte{0x2a}, "0x2a"},
+		{"i", []byte{0xfb, 0xff, 0xff, 0xff}, "-5"},
+		{"u", []byte{7, 0, 0, 0}, "7"},
+		{"x", []byte{0, 0, 0, 0, 1, 0, 0, 0}, "4294967296"},
+		{"d", []byte{0, 0, 0, 0, 0, 0, 0xe0, 0x3f}, "0.5"},
+		{"s", []byte("it's\x00"), `"it's"`},
+		{"as", []byte("a\x00bc\x00\x02\x05"), "['a', 'bc']"},
+		{"ai", []byte{}, "[]"},
+		{"ai", []byte{1, 0, 0, 0, 2, 0, 0, 0}, "[1, 2]"},
+		{"(ii)", []byte{0x80, 2, 0, 0, 0xe0, 1, 0, 0}, "(640, 480)"},
+		{"(si)", []byte("a\x00\x00\x00\x01\x00\x00\x00\x02"), "('a', 1)"},
+		{"(s)", []byte("a\x00"), "('a',)"},
+		{"a{sb}", []byte("a\x00\x01\x02\x04"), "{'a': true}"},
+		{"v", []byte("\x01\x00\x00\x00\x00i"), "<1>"},
+	}
+
+	for _, test := range tests {
+		typ, typeErr := parseGVariantType(test.typ)
+
+		if typeErr != nil {
+			t.Fatalf("Failed to parse type %s: %s", test.typ, typeErr)
+		}
+
+		text, textErr := gvariantValue{data: test.data, order: binary.LittleEndian, typ: typ}.text()
+
+		if textErr != nil {
+			t.Errorf("Failed to print %s value %v: %s", test.typ, test.data, textErr)
+		} else if text != test.text {
+			t.Errorf("Expected %s value %v to print as %s, got %s instead.", test.typ, test.data, test.text, text)
+		}
+	}
+}
+
+// TestGVariantValueInvalid will test that malformed values and types are rejected rather than read out of bounds
+func TestGVariantValueInvalid(t *testing.T) {
+	tests := []struct {
+		typ  string
+		data []byte
+	}{
+		{"i", []byte{1, 0, 0}},
+		{"(ii)", []byte{1, 0, 0, 0, 2}},
+		{"as", []byte("a\x00\x09")},
+		{"as", []byte("a\x00\x00\x05\x02")},
+		{"s", []byte("no nul")},
+	}
+
+	for _, test := range tests {
+		typ, _ := parseGVariantType(test.typ)
+
+		if _, textErr := (gvariantValue{data: test.data, order: binary.LittleEndian, typ: typ}).text(); !errors.Is(textErr, ErrGVDBInvalid) {
+			t.Errorf("Expected ErrGVDBInvalid for %s value %v, got %v instead.", test.typ, test.data, textErr)
+		}
+	}
+
+	for _, typeString := range []string{"", "r", "a*", "(i"} {
+		if _, typeErr := parseGVariantType(typeString); !errors.Is(typeErr, ErrGVDBInvalid) {
+			t.Errorf("Expected ErrGVDBInvalid for type %q, got %v instead.", typeString, typeErr)
+		}
+	}
+}
@@ -0,0 +1,521 @@
+/* gvariantText.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// dconf dumps values in the GVariant text format without their types, which GSettings knows from its schemas
+// This parses values in that format far enough to check them against a GVariant type, like one of a GSchemaKey
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gvariantKeywords are the type keywords which may precede a value in the GVariant text format, like uint32 5
+var gvariantKeywords = map[string]string{
+	"boolean":    "b",
+	"byte":       "y",
+	"double":     "d",
+	"handle":     "h",
+	"int16":      "n",
+	"int32":      "i",
+	"int64":      "x",
+	"objectpath": "o",
+	"signature":  "g",
+	"string":     "s",
+	"uint16":     "q",
+	"uint32":     "u",
+	"uint64":     "t",
+}
+
+// gvariantTextValue is a value parsed from the GVariant text format
+type gvariantTextValue struct {
+	annotation string               // Type the value was annotated with, like u for uint32 5 or as for @as []
+	items      []*gvariantTextValue // Items of an array, members of a tuple or dictionary entry, entries of a dictionary, or the value of a variant or just
+	kind       byte                 // b for booleans, 0 for numbers, s for strings, y for byte strings, [ for arrays, ( for tuples, { for dictionaries, e for dictionary entries, < for variants, j for just and m for nothing
+	text       string               // Number, or decoded string
+}
+
+// gvariantTextParser parses a value in the GVariant text format
+type gvariantTextParser struct {
+	index int
+	text  string
+}
+
+// parseGVariantText will parse the value in the GVariant text format
+func parseGVariantText(text string) (value *gvariantTextValue, parseErr error) {
+	parser := &gvariantTextParser{text: text}
+
+	if value, parseErr = parser.value(); parseErr != nil {
+		return nil, parseErr
+	}
+
+	if parser.skipSpace(); parser.index != len(text) {
+		return nil, parser.fail("unexpected %q after the value", text[parser.index:])
+	}
+
+	return
+}
+
+// value will parse the value starting at our index
+func (parser *gvariantTextParser) value() (*gvariantTextValue, error) {
+	parser.skipSpace()
+	rest := parser.text[parser.index:]
+
+	switch char := parser.peek(); {
+	case char == 0:
+		return nil, parser.fail("expected a value")
+	case char == '@': // Type annotation, like @as
+		end, valid := scanGVariantType(parser.text, parser.index+1)
+
+		if !valid {
+			return nil, parser.fail("invalid type annotation")
+		}
+
+		annotation := parser.text[parser.index+1 : end]
+		parser.index = end
+		return parser.annotated(annotation)
+	case char == '\'' || char == '"':
+		return parser.str('s')
+	case char == 'b' && len(rest) > 1 && (rest[1] == '\'' || rest[1] == '"'): // Byte string, like b'abc'
+		parser.index++
+		return parser.str('y')
+	case char == '[', char == '(':
+		closing := map[byte]byte{'[': ']', '(': ')'}[char]
+		items, itemsErr := parser.items(closing)
+		return &gvariantTextValue{items: items, kind: char}, itemsErr
+	case char == '{':
+		return parser.dictionary()
+	case char == '<':
+		parser.index++
+		item, itemErr := parser.value()
+
+		if itemErr != nil {
+			return nil, itemErr
+		}
+
+		if parser.skipSpace(); parser.peek() != '>' {
+			return nil, parser.fail("expected >")
+		}
+
+		parser.index++
+		return &gvariantTextValue{items: []*gvariantTextValue{item}, kind: '<'}, nil
+	}
+
+	word := parser.word()
+
+	switch word {
+	case "":
+		return nil, parser.fail("unexpected %q", rest[:1])
+	case "true", "false":
+		return &gvariantTextValue{kind: 'b', text: word}, nil
+	case "nothing":
+		return &gvariantTextValue{kind: 'm'}, nil
+	case "just":
+		item, itemErr := parser.value()
+		return &gvariantTextValue{items: []*gvariantTextValue{item}, kind: 'j'}, itemErr
+	}
+
+	if annotation, isKeyword := gvariantKeywords[word]; isKeyword {
+		return parser.annotated(annotation)
+	}
+
+	if _, isNumber := parseGVariantNumber(word); !isNumber {
+		return nil, parser.fail("unexpected %q", word)
+	}
+
+	return &gvariantTextValue{kind: '0', text: word}, nil
+}
+
+// annotated will parse the value following a type annotation
+func (parser *gvariantTextParser) annotated(annotation string) (*gvariantTextValue, error) {
+	value, valueErr := parser.value()
+
+	if valueErr != nil {
+		return nil, valueErr
+	}
+
+	if value.annotation != "" && value.annotation != annotation {
+		return nil, parser.fail("conflicting type annotations %s and %s", annotation, value.annotation)
+	}
+
+	value.annotation = annotation
+	return value, nil
+}
+
+// dictionary will parse a dictionary, like {'a': 1}, or a single dictionary entry, like {'a', 1}
+func (parser *gvariantTextParser) dictionary() (*gvariantTextValue, error) {
+	parser.index++ // Opening {
+	dictionary := &gvariantTextValue{items: []*gvariantTextValue{}, kind: '{'}
+
+	if parser.skipSpace(); parser.peek() == '}' { // Empty dictionary
+		parser.index++
+		return dictionary, nil
+	}
+
+	for {
+		key, keyErr := parser.value()
+
+		if keyErr != nil {
+			return nil, keyErr
+		}
+
+		parser.skipSpace()
+		separator := parser.peek()
+
+		if separator != ':' && (separator != ',' || len(dictionary.items) != 0) {
+			return nil, parser.fail("expected :")
+		}
+
+		parser.index++
+		value, valueErr := parser.value()
+
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		entry := &gvariantTextValue{items: []*gvariantTextValue{key, value}, kind: 'e'}
+		parser.skipSpace()
+
+		switch parser.peek() {
+		case '}':
+			parser.index++
+
+			if separator == ',' { // Single entry
+				return entry, nil
+			}
+
+			dictionary.items = append(dictionary.items, entry)
+			return dictionary, nil
+		case ',':
+			if separator == ',' {
+				return nil, parser.fail("expected }")
+			}
+
+			parser.index++
+			dictionary.items = append(dictionary.items, entry)
+		default:
+			return nil, parser.fail("expected , or }")
+		}
+	}
+}
+
+// items will parse the comma separated items of an array or tuple, up to the closing bracket
+func (parser *gvariantTextParser) items(closing byte) (items []*gvariantTextValue, itemsErr error) {
+	parser.index++ // Opening bracket
+	items = []*gvariantTextValue{}
+
+	for {
+		if parser.skipSpace(); parser.peek() == closing {
+			parser.index++
+			return
+		}
+
+		if len(items) != 0 {
+			if parser.peek() != ',' {
+				return nil, parser.fail("expected , or %c", closing)
+			}
+
+			if parser.index++; parser.skipSpace() == closing { // Trailing comma, as in ('a',)
+				parser.index++
+				return
+			}
+		}
+
+		item, itemErr := parser.value()
+
+		if itemErr != nil {
+			return nil, itemErr
+		}
+
+		items = append(items, item)
+	}
+}
+
+// str will parse the quoted string starting at our index
+func (parser *gvariantTextParser) str(kind byte) (*gvariantTextValue, error) {
+	quote := parser.peek()
+	end := parser.index + 1
+
+	for ; end < len(parser.text) && parser.text[end] != quote; end++ {
+		if parser.text[end] == '\\' {
+			end++
+		}
+	}
+
+	if end >= len(parser.text) {
+		return nil, parser.fail("unterminated string")
+	}
+
+	str, unquoteErr := UnquoteString(parser.text[parser.index : end+1])
+
+	if unquoteErr != nil {
+		return nil, unquoteErr
+	}
+
+	parser.index = end + 1
+	return &gvariantTextValue{kind: kind, text: str}, nil
+}
+
+// word will scan a keyword or number starting at our index
+func (parser *gvariantTextParser) word() string {
+	start := parser.index
+
+	for ; parser.index < len(parser.text); parser.index++ {
+		char := parser.text[parser.index]
+
+		if !(char >= 'a' && char <= 'z') && !(char >= 'A' && char <= 'Z') && !(char >= '0' && char <= '9') && strings.IndexByte("_.+-", char) == -1 {
+			break
+		}
+	}
+
+	return parser.text[start:parser.index]
+}
+
+// peek will return the byte at our index, or 0 at the end of the text
+func (parser *gvariantTextParser) peek() byte {
+	if parser.index >= len(parser.text) {
+		return 0
+	}
+
+	return parser.text[parser.index]
+}
+
+// skipSpace will skip any whitespace at our index, returning the byte after it
+func (parser *gvariantTextParser) skipSpace() byte {
+	for parser.index < len(parser.text) && strings.IndexByte(" \t\n\r", parser.text[parser.index]) != -1 {
+		parser.index++
+	}
+
+	return parser.peek()
+}
+
+// fail will return an error for the problem at our index
+func (parser *gvariantTextParser) fail(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: column %d: %s: %s", ErrParseInvalidValue, parser.index+1, fmt.Sprintf(format, args...), parser.text)
+}
+
+// matches will check if the value is of the type
+// Numbers match any numeric type they fit in, and a value may be given for a maybe type without a just
+func (value *gvariantTextValue) matches(typ *gvariantType) bool {
+	if value.annotation != "" && value.annotation != typ.str {
+		return false
+	}
+
+	switch typ.kind {
+	case 'b':
+		return value.kind == 'b'
+	case 'y', 'n', 'q', 'i', 'u', 'x', 't', 'h':
+		if value.kind != '0' {
+			return false
+		}
+
+		bits := map[byte]int{'y': 8, 'n': 16, 'q': 16, 'i': 32, 'u': 32, 'x': 64, 't': 64, 'h': 32}[typ.kind]
+
+		if typ.kind == 'y' || typ.kind == 'q' || typ.kind == 'u' || typ.kind == 't' {
+			_, parseErr := parseGVariantUint(value.text, bits)
+			return parseErr == nil
+		}
+
+		_, parseErr := parseGVariantInt(value.text, bits)
+		return parseErr == nil
+	case 'd':
+		return value.kind == '0'
+	case 's', 'o', 'g':
+		return value.kind == 's'
+	case 'v':
+		return value.kind == '<'
+	case 'm':
+		switch value.kind {
+		case 'm':
+			return true
+		case 'j':
+			return value.items[0].matches(typ.elements[0])
+		default:
+			return value.matches(typ.elements[0])
+		}
+	case 'a':
+		switch {
+		case value.kind == 'y':
+			return typ.str == "ay"
+		case value.kind == '{' && typ.elements[0].kind != '{':
+			return false
+		case value.kind != '[' && value.kind != '{':
+			return false
+		}
+
+		for _, item := range value.items {
+			if !item.matches(typ.elements[0]) {
+				return false
+			}
+		}
+
+		return true
+	case '(', '{':
+		if (typ.kind == '(' && value.kind != '(') || (typ.kind == '{' && value.kind != 'e') || len(value.items) != len(typ.elements) {
+			return false
+		}
+
+		for index, item := range value.items {
+			if !item.matches(typ.elements[index]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// gvariantInteger will split an integer in the GVariant text format into its sign and digits, and the base of those digits
+// GVariant accepts decimal, hexadecimal with a 0x prefix and octal with a leading 0, but not the 0b and 0o prefixes or _ separators that Go does
+func gvariantInteger(text string) (sign string, digits string, base int, ok bool) {
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		sign, text = text[:1], text[1:]
+	}
+
+	base, allowed := 10, "0123456789"
+
+	switch {
+	case strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X"):
+		base, allowed, text = 16, "0123456789abcdefABCDEF", text[2:]
+	case len(text) > 1 && text[0] == '0':
+		base, allowed, text = 8, "01234567", text[1:]
+	}
+
+	if text == "" || strings.Trim(text, allowed) != "" {
+		return "", "", 0, false
+	}
+
+	return sign, text, base, true
+}
+
+// parseGVariantInt will parse the signed integer, in the GVariant text format, with the bit size
+func parseGVariantInt(text string, bits int) (int64, error) {
+	sign, digits, base, ok := gvariantInteger(text)
+
+	if !ok {
+		return 0, strconv.ErrSyntax
+	}
+
+	return strconv.ParseInt(sign+digits, base, bits)
+}
+
+// parseGVariantUint will parse the unsigned integer, in the GVariant text format, with the bit size
+func parseGVariantUint(text string, bits int) (uint64, error) {
+	sign, digits, base, ok := gvariantInteger(text)
+
+	if !ok || sign == "-" {
+		return 0, strconv.ErrSyntax
+	}
+
+	return strconv.ParseUint(digits, base, bits)
+}
+
+// parseGVariantNumber will parse the number, in the GVariant text format, as a float64 for comparisons
+func parseGVariantNumber(text string) (float64, bool) {
+	if integer, parseErr := parseGVariantInt(text, 64); parseErr == nil {
+		return float64(integer), true
+	}
+
+	if integer, parseErr := parseGVariantUint(text, 64); parseErr == nil {
+		return float64(integer), true
+	}
+
+	if strings.ContainsRune(text, '_') { // Go only accepts these after a base prefix, GVariant never does
+		return 0, false
+	}
+
+	if unsigned := strings.TrimLeft(text, "+-"); strings.EqualFold(unsigned, "infinity") || (strings.EqualFold(unsigned, "inf") && unsigned != "inf") || (strings.EqualFold(unsigned, "nan") && unsigned != "nan") {
+		return 0, false // Go also accepts Infinity and any case, GVariant only inf and nan
+	}
+
+	number, parseErr := strconv.ParseFloat(text, 64)
+	return number, parseErr == nil
+}
+
+// compareGVariantNumbers will compare two numbers in the GVariant text format, which may have type annotations
+// Integers are compared exactly, returning -1, 0 or 1, and false is returned if either is not a number
+func compareGVariantNumbers(a string, b string) (int, bool) {
+	aValue, aErr := parseGVariantText(a)
+	bValue, bErr := parseGVariantText(b)
+
+	if aErr != nil || bErr != nil || aValue.kind != '0' || bValue.kind != '0' {
+		return 0, false
+	}
+
+	aInt, aIntErr := parseGVariantInt(aValue.text, 64)
+	bInt, bIntErr := parseGVariantInt(bValue.text, 64)
+
+	if aIntErr == nil && bIntErr == nil {
+		switch {
+		case aInt < bInt:
+			return -1, true
+		case aInt > bInt:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	aFloat, _ := parseGVariantNumber(aValue.text)
+	bFloat, _ := parseGVariantNumber(bValue.text)
+
+	switch {
+	case aFloat < bFloat:
+		return -1, true
+	case aFloat > bFloat:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+// gvariantTextEqual will check if both values in the GVariant text format are the same value
+// Type annotations are ignored, as values of a key share its type, and numbers are compared by value
+func gvariantTextEqual(a string, b string) bool {
+	aValue, aErr := parseGVariantText(a)
+	bValue, bErr := parseGVariantText(b)
+
+	return aErr == nil && bErr == nil && aValue.equal(bValue)
+}
+
+// equal will check if both values are the same, ignoring type annotations and comparing numbers by value
+func (value *gvariantTextValue) equal(other *gvariantTextValue) bool {
+	if value.kind != other.kind || len(value.items) != len(other.items) {
+		return false
+	}
+
+	if value.kind == '0' {
+		compared, ok := compareGVariantNumbers(value.text, other.text)
+		return ok && compared == 0
+	}
+
+	if value.text != other.text {
+		return false
+	}
+
+	for index, item := range value.items {
+		if !item.equal(other.items[index]) {
+			return false
+		}
+	}
+
+	return true
+}
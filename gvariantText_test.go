@@ -0,0 +1,135 @@
+/* gvariantText_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestGVariantTextMatches will test checking values in the GVariant text format against types
+func TestGVariantTextMatches(t *testing.T) {
+	tests := []struct {
+		typ     string
+		text    string
+		matches bool
+	}{
+		{"b", "true", true},
+		{"b", "1", false},
+		{"i", "-5", true},
+		{"i", "2147483648", false},
+		{"u", "-1", false},
+		{"u", "uint32 5", true},
+		{"i", "uint32 5", false},
+		{"y", "0x2a", true},
+		{"y", "256", false},
+		{"i", "010", true},
+		{"y", "0377", true},
+		{"i", "09", false},
+		{"i", "+5", true},
+		{"u", "+5", true},
+		{"x", "int64 -9000000000", true},
+		{"d", "0.5", true},
+		{"d", "1", true},
+		{"i", "0.5", false},
+		{"s", "'it\\'s'", true},
+		{"s", `"it's"`, true},
+		{"s", "5", false},
+		{"as", "[]", true},
+		{"as", "@as []", true},
+		{"as", "@ai []", false},
+		{"as", "['a', \"b\"]", true},
+		{"as", "['a', 1]", false},
+		{"ay", "b'bytes'", true},
+		{"(ii)", "(640, 480)", true},
+		{"(ii)", "(640,)", false},
+		{"(s)", "('a',)", true},
+		{"a{sv}", "{'a': <1>, 'b': <'c'>}", true},
+		{"a{sv}", "{}", true},
+		{"a{si}", "{'a': 'b'}", false},
+		{"a{si}", "[{'a', 1}]", true},
+		{"{si}", "{'a', 1}", true},
+		{"mi", "nothing", true},
+		{"mi", "just 5", true},
+		{"mi", "5", true},
+		{"v", "<[1, 2]>", true},
+		{"aas", "[['a'], []]", true},
+	}
+
+	for _, test := range tests {
+		typ, _ := parseGVariantType(test.typ)
+		value, parseErr := parseGVariantText(test.text)
+
+		if parseErr != nil {
+			t.Errorf("Failed to parse %s: %s", test.text, parseErr)
+		} else if matches := value.matches(typ); matches != test.matches {
+			t.Errorf("Expected %s matching %s to be %t, got %t instead.", test.text, test.typ, test.matches, matches)
+		}
+	}
+}
+
+// TestParseGVariantTextInvalid will test that malformed values are rejected
+func TestParseGVariantTextInvalid(t *testing.T) {
+	for _, text := range []string{"", "[1, 2", "(1 2)", "'unterminated", "{'a': 1, 'b'}", "{'a', 1, 2}", "<1", "maybe", "@q", "uint32 @i 5", "1 2", "0b101", "0o17", "1_000", "0x1_0p0", "Infinity"} {
+		if _, parseErr := parseGVariantText(text); !errors.Is(parseErr, ErrParseInvalidValue) {
+			t.Errorf("Expected ErrParseInvalidValue for %q, got %v instead.", text, parseErr)
+		}
+	}
+}
+
+// TestCompareGVariantNumbers will test comparing numbers, exactly for integers
+func TestCompareGVariantNumbers(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1", "2", -1},
+		{"9007199254740993", "9007199254740992", 1},
+		{"uint32 5", "5", 0},
+		{"0.5", "1", -1},
+		{"0x10", "16.0", 0},
+	}
+
+	for _, test := range tests {
+		if compared, ok := compareGVariantNumbers(test.a, test.b); !ok || compared != test.expected {
+			t.Errorf("Expected comparing %s to %s to be %d, got %d (%t) instead.", test.a, test.b, test.expected, compared, ok)
+		}
+	}
+
+	if _, ok := compareGVariantNumbers("'a'", "1"); ok {
+		t.Error("Expected comparing a string to fail")
+	}
+}
+
+// TestGVariantTextEqual will test comparing values regardless of formatting and annotations
+func TestGVariantTextEqual(t *testing.T) {
+	equal := [][2]string{{"0", "uint32 0"}, {"0.5", "5e-1"}, {`"a"`, "'a'"}, {"@as []", "[]"}, {"['a','b']", "['a', 'b']"}, {"(1, 'a')", "(1,'a')"}}
+	different := [][2]string{{"0", "'0'"}, {"['a']", "['a', 'b']"}, {"true", "false"}, {"'a'", "'b'"}, {"[1]", "[2]"}}
+
+	for _, pair := range equal {
+		if !gvariantTextEqual(pair[0], pair[1]) {
+			t.Errorf("Expected %s to equal %s", pair[0], pair[1])
+		}
+	}
+
+	for _, pair := range different {
+		if gvariantTextEqual(pair[0], pair[1]) {
+			t.Errorf("Expected %s to differ from %s", pair[0], pair[1])
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/* gvariant_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestQuoteString will test that QuoteString and UnquoteString round trip
+func TestQuoteString(t *testing.T) {
+	tests := map[string]string{
+		"Clock":         "'Clock'",
+		"it's":          `"it's"`,
+		`it's "quoted"`: `'it\'s "quoted"'`,
+		"tab\there":     `'tab\there'`,
+		`back\slash`:    `'back\\slash'`,
+		"日本":            "'日本'",
+	}
+
+	for str, expected := range tests {
+		if quoted := QuoteString(str); quoted != expected {
+			t.Errorf("Expected %s quoted to be %s, got %s instead.", str, expected, quoted)
+		}
+
+		if unquoted, unquoteErr := UnquoteString(QuoteString(str)); unquoteErr != nil || unquoted != str {
+			t.Errorf("Failed to round trip %q, got %q (%v)", str, unquoted, unquoteErr)
+		}
+	}
+
+	if unquoted, _ := UnquoteString(`'é\n'`); unquoted != "é\n" {
+		t.Errorf("Failed to decode escapes, got %q", unquoted)
+	}
+
+	for _, raw := range []string{"Clock", "'Clock", "'a' 'b'", `'\u00'`} {
+		if _, unquoteErr := UnquoteString(raw); unquoteErr == nil {
+			t.Errorf("Expected %s to fail to unquote", raw)
+		}
+	}
+}
+
+// TestSplitArray will test SplitArray
+func TestSplitArray(t *testing.T) {
+	tests := map[string]string{
+		"['a', 'b']":           "'a'|'b'",
+		"[]":                   "",
+		"@as []":               "",
+		"['a, b', \"c']\"]":    "'a, b'|\"c']\"",
+		"[(1, 'x'), (2, 'y')]": "(1, 'x')|(2, 'y')",
+		"[uint32 1, uint32 2]": "uint32 1|uint32 2",
+	}
+
+	for raw, expected := range tests {
+		items, splitErr := SplitArray(raw)
+
+		if splitErr != nil || strings.Join(items, "|") != expected {
+			t.Errorf("Expected %s to split into %s, got %v (%v)", raw, expected, items, splitErr)
+		}
+	}
+
+	for _, raw := range []string{"'a'", "['a'", "['a]"} {
+		if _, splitErr := SplitArray(raw); splitErr == nil {
+			t.Errorf("Expected %s to fail to split", raw)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+/* gvdb.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// GVDB is the simple database format used by gschemas.compiled (and dconf's own databases)
+// A file is a header followed by hash tables, whose items are GVariant values or further hash tables
+// We only read it, and rather than hashing keys to look them up we read every item of a table
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	gvdbHeaderSize = 24 // Signature, version, options and root pointer
+	gvdbItemSize   = 24 // Hash, parent, key start and size, type, and value pointer
+)
+
+// gvdbFile is the content of a GVDB file and the byte order it was written in
+type gvdbFile struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// gvdbItem is an item of a GVDB hash table
+type gvdbItem struct {
+	key        string // Full key, including the keys of any parents
+	kind       byte   // v for a GVariant value, H for a hash table and L for a list
+	valueEnd   uint32
+	valueStart uint32
+}
+
+// openGVDB will check the header of the GVDB file, returning the items of its root table
+func openGVDB(content []byte) (file *gvdbFile, root []gvdbItem, openErr error) {
+	if len(content) < gvdbHeaderSize {
+		return nil, nil, fmt.Errorf("%w: too short", ErrGVDBInvalid)
+	}
+
+	file = &gvdbFile{data: content}
+
+	switch signature := string(content[:8]); signature {
+	case "GVariant":
+		file.order = binary.LittleEndian
+	case "raVGtnai": // Written on a big endian machine
+		file.order = binary.BigEndian
+	default:
+		return nil, nil, fmt.Errorf("%w: bad signature", ErrGVDBInvalid)
+	}
+
+	if version := file.order.Uint32(content[8:]); version != 0 {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrGVDBInvalid, version)
+	}
+
+	root, openErr = file.table(file.order.Uint32(content[16:]), file.order.Uint32(content[20:]))
+	return
+}
+
+// table will read the items of the hash table between the provided offsets
+func (file *gvdbFile) table(start uint32, end uint32) (items []gvdbItem, tableErr error) {
+	data, sliceErr := file.slice(start, end)
+
+	if sliceErr != nil {
+		return nil, sliceErr
+	}
+
+	if len(data) < 8 {
+		return nil, fmt.Errorf("%w: hash table too short", ErrGVDBInvalid)
+	}
+
+	bloomWords := uint64(file.order.Uint32(data) & (1<<27 - 1)) // Top 5 bits are the bloom shift
+	buckets := uint64(file.order.Uint32(data[4:]))
+	itemsStart := 8 + (bloomWords+buckets)*4
+
+	if itemsStart > uint64(len(data)) || (uint64(len(data))-itemsStart)%gvdbItemSize != 0 {
+		return nil, fmt.Errorf("%w: hash table has the wrong size", ErrGVDBInvalid)
+	}
+
+	count := (uint64(len(data)) - itemsStart) / gvdbItemSize
+	parents := make([]uint32, count)
+	names := make([]string, count)
+	items = make([]gvdbItem, count)
+
+	for index := uint64(0); index < count; index++ {
+		raw := data[itemsStart+index*gvdbItemSize:]
+		keyStart, keySize := file.order.Uint32(raw[8:]), uint32(file.order.Uint16(raw[12:]))
+
+		name, nameErr := file.slice(keyStart, keyStart+keySize)
+
+		if nameErr != nil {
+			return nil, nameErr
+		}
+
+		parents[index] = file.order.Uint32(raw[4:])
+		names[index] = string(name)
+		items[index] = gvdbItem{
+			kind:       raw[14],
+			valueEnd:   file.order.Uint32(raw[20:]),
+			valueStart: file.order.Uint32(raw[16:]),
+		}
+	}
+
+	for index := range items { // Keys are relative to their parent's
+		key := names[index]
+		parent := parents[index]
+
+		for depth := uint64(0); parent != 0xffffffff; depth++ {
+			if uint64(parent) >= count || depth >= count { // Out of range, or a cycle
+				return nil, fmt.Errorf("%w: invalid parent", ErrGVDBInvalid)
+			}
+
+			key = names[parent] + key
+			parent = parents[parent]
+		}
+
+		items[index].key = key
+	}
+
+	return
+}
+
+// subtable will read the items of the hash table the item points to
+func (file *gvdbFile) subtable(item gvdbItem) ([]gvdbItem, error) {
+	if item.kind != 'H' {
+		return nil, fmt.Errorf("%w: %s is not a hash table", ErrGVDBInvalid, item.key)
+	}
+
+	return file.table(item.valueStart, item.valueEnd)
+}
+
+// value will return the GVariant value the item holds
+func (file *gvdbFile) value(item gvdbItem) (value gvariantValue, valueErr error) {
+	if item.kind != 'v' {
+		valueErr = fmt.Errorf("%w: %s is not a value", ErrGVDBInvalid, item.key)
+		return
+	}
+
+	data, sliceErr := file.slice(item.valueStart, item.valueEnd)
+
+	if sliceErr != nil {
+		valueErr = sliceErr
+		return
+	}
+
+	boxed := gvariantValue{data: data, order: file.order, typ: &gvariantType{alignment: 8, kind: 'v', str: "v"}}
+	children, childErr := boxed.children() // Values are stored within a variant
+
+	if childErr != nil {
+		valueErr = childErr
+		return
+	}
+
+	return children[0], nil
+}
+
+// slice will return the bytes between the offsets, if they are within the file
+func (file *gvdbFile) slice(start uint32, end uint32) ([]byte, error) {
+	if start > end || uint64(end) > uint64(len(file.data)) {
+		return nil, fmt.Errorf("%w: pointer out of range", ErrGVDBInvalid)
+	}
+
+	return file.data[start:end], nil
+}
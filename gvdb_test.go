@@ -0,0 +1,74 @@
+/* gvdb_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// CompiledGSchemasFile is our example gschemas.compiled, compiled from examples/schemas and GSchemaXML
+const CompiledGSchemasFile = "examples/image/usr/share/glib-2.0/schemas/gschemas.compiled"
+
+// TestOpenGVDB will test reading the root table of a GVDB file
+func TestOpenGVDB(t *testing.T) {
+	content, readErr := os.ReadFile(CompiledGSchemasFile)
+
+	if readErr != nil {
+		t.Fatalf("Failed to read: %s", readErr)
+	}
+
+	file, root, openErr := openGVDB(content)
+
+	if openErr != nil {
+		t.Fatalf("Failed to open: %s", openErr)
+	}
+
+	var found bool
+
+	for _, item := range root {
+		if item.key != "org.example.window" {
+			continue
+		}
+
+		found = true
+		items, tableErr := file.subtable(item)
+
+		if tableErr != nil || len(items) == 0 || items[0].key != "size" || items[0].kind != 'v' {
+			t.Errorf("Unexpected table %+v (%v)", items, tableErr)
+		}
+	}
+
+	if !found {
+		t.Errorf("Expected org.example.window in the root table, got %+v", root)
+	}
+}
+
+// TestOpenGVDBInvalid will test that invalid or truncated files are rejected
+func TestOpenGVDBInvalid(t *testing.T) {
+	content, _ := os.ReadFile(CompiledGSchemasFile)
+	badVersion := append([]byte{}, content...)
+	badVersion[8] = 1
+
+	for _, invalid := range [][]byte{[]byte("GVariant"), []byte("NotGVDB\x00" + string(content[8:])), badVersion, content[:gvdbHeaderSize]} {
+		if _, _, openErr := openGVDB(invalid); !errors.Is(openErr, ErrGVDBInvalid) {
+			t.Errorf("Expected ErrGVDBInvalid, got %v instead.", openErr)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+/* keyPath.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// dconf addresses keys by path, like /com/solus-project/budgie-panel/applets/{uuid}/name
+// A Schema's sections are relative to its Path, so that key is in the applets/{uuid} section of a Schema with the Path /com/solus-project/budgie-panel/
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitKeyPath will split the dconf key path into its directory, with a trailing slash, and key
+func SplitKeyPath(keyPath string) (dir string, key string, splitErr error) {
+	if !strings.HasPrefix(keyPath, "/") || strings.HasSuffix(keyPath, "/") || strings.Contains(keyPath, "//") {
+		splitErr = fmt.Errorf("%w: %q", ErrInvalidKeyPath, keyPath)
+		return
+	}
+
+	lastSlash := strings.LastIndex(keyPath, "/")
+	dir, key = keyPath[:lastSlash+1], keyPath[lastSlash+1:]
+	return
+}
+
+// KeyPath will return the full dconf key path of the key in the provided section of our Schema
+func (schema *Schema) KeyPath(section string, key string) string {
+	if section == "/" { // Root of our Path
+		return schema.basePath() + key
+	}
+
+	return schema.basePath() + TrimSectionSlashes(section) + "/" + key
+}
+
+// Delete will delete the key at the provided dconf key path, deleting its section if no keys remain
+// Paths not starting with / are relative to our Path
+func (schema *Schema) Delete(keyPath string) error {
+	section, key, resolveErr := schema.resolveKeyPath(keyPath)
+
+	if resolveErr != nil {
+		return resolveErr
+	}
+
+	kv, exists := schema.Map[section]
+
+	if !exists || !kv.HasKey(key) { // Nothing to delete
+		return ErrKeyNotExists
+	}
+
+	kv.DeleteKeys(key)
+
+	if len(kv.Keys) == 0 { // Like dconf, a section only exists while it has keys
+		schema.DeleteSections(section)
+	}
+
+	return nil
+}
+
+// Exists will check if we have a key at the provided dconf key path
+// Paths not starting with / are relative to our Path
+func (schema *Schema) Exists(keyPath string) bool {
+	_, getErr := schema.Get(keyPath)
+	return getErr == nil
+}
+
+// Get will get the SchemaType of the key at the provided dconf key path
+// Paths not starting with / are relative to our Path
+func (schema *Schema) Get(keyPath string) (*SchemaType, error) {
+	section, key, resolveErr := schema.resolveKeyPath(keyPath)
+
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+
+	kv, getErr := schema.GetSection(section)
+
+	if getErr != nil { // Section does not exist
+		return nil, getErr
+	}
+
+	return kv.GetVal(key)
+}
+
+// Set will set the key at the provided dconf key path to the SchemaType, creating its section if needed
+// Paths not starting with / are relative to our Path
+func (schema *Schema) Set(keyPath string, t *SchemaType) error {
+	section, key, resolveErr := schema.resolveKeyPath(keyPath)
+
+	if resolveErr != nil {
+		return resolveErr
+	}
+
+	if !schema.HasSection(section) { // Create the section
+		newKv := &SchemaKV{
+			Order: []string{},
+			Keys:  make(map[string]*SchemaType),
+		}
+
+		if addErr := schema.AddSection(section, newKv); addErr != nil {
+			return addErr
+		}
+	}
+
+	kv := schema.Map[section]
+
+	if kv.HasKey(key) { // Replace, keeping its place in our Order
+		kv.Keys[key] = t
+		return nil
+	}
+
+	return kv.AddKey(key, t)
+}
+
+// basePath will return our Path with a trailing slash, defaulting to / like ImportIntoDconfAtPath
+func (schema *Schema) basePath() string {
+	if schema.Path == "" {
+		return "/"
+	}
+
+	return strings.TrimSuffix(schema.Path, "/") + "/"
+}
+
+// resolveKeyPath will resolve the dconf key path to the section and key within our Schema
+func (schema *Schema) resolveKeyPath(keyPath string) (section string, key string, resolveErr error) {
+	if !strings.HasPrefix(keyPath, "/") { // Relative to our Path
+		keyPath = schema.basePath() + keyPath
+	}
+
+	var dir string
+	if dir, key, resolveErr = SplitKeyPath(keyPath); resolveErr != nil {
+		return
+	}
+
+	base := schema.basePath()
+
+	if !strings.HasPrefix(dir, base) { // Not under our Path
+		resolveErr = fmt.Errorf("%w: %q is not under %q", ErrKeyPathOutsideSchema, keyPath, base)
+		return
+	}
+
+	if section = TrimSectionSlashes(strings.TrimPrefix(dir, base)); section == "" { // Directly under our Path
+		section = "/"
+	}
+
+	if resolveErr = ValidateKeyName(key); resolveErr != nil {
+		return
+	}
+
+	return
+}
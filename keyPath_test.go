@@ -0,0 +1,88 @@
+/* keyPath_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestSplitKeyPath will test SplitKeyPath
+func TestSplitKeyPath(t *testing.T) {
+	dir, key, splitErr := SplitKeyPath("/com/solus-project/budgie-panel/applets/{uuid}/name")
+
+	if splitErr != nil || dir != "/com/solus-project/budgie-panel/applets/{uuid}/" || key != "name" {
+		t.Errorf("Unexpected split: %q %q %v", dir, key, splitErr)
+	}
+
+	for _, keyPath := range []string{"", "relative/name", "/dir/", "/a//b"} {
+		if _, _, splitErr = SplitKeyPath(keyPath); !errors.Is(splitErr, ErrInvalidKeyPath) {
+			t.Errorf("Expected ErrInvalidKeyPath for %q, got %v instead.", keyPath, splitErr)
+		}
+	}
+}
+
+// TestKeyPathAccess will test Get, Set, Delete and Exists
+func TestKeyPathAccess(t *testing.T) {
+	schema := NewTestSchema(t)
+	namePath := "/com/solus-project/budgie-panel/" + TestSectionID + "/name"
+
+	if schema.KeyPath(TestSectionID, "name") != namePath {
+		t.Errorf("Unexpected KeyPath: %s", schema.KeyPath(TestSectionID, "name"))
+	}
+
+	if sT, getErr := schema.Get(namePath); getErr != nil || sT.Val != "'Lock Keys Indicator'" {
+		t.Errorf("Failed to get %s: %v %v", namePath, sT, getErr)
+	}
+
+	if _, getErr := schema.Get(TestSectionID + "/name"); getErr != nil { // Relative to our Path
+		t.Errorf("Failed to get relative path: %s", getErr)
+	}
+
+	if _, getErr := schema.Get("/org/gnome/desktop/name"); !errors.Is(getErr, ErrKeyPathOutsideSchema) {
+		t.Errorf("Expected ErrKeyPathOutsideSchema, got %v instead.", getErr)
+	}
+
+	if _, getErr := schema.Get("/com/solus-project/budgie-panel/nope/name"); getErr != ErrSectionDoesNotExist {
+		t.Errorf("Expected ErrSectionDoesNotExist, got %v instead.", getErr)
+	}
+
+	sT, _ := NewSchemaType("'Renamed'")
+
+	if setErr := schema.Set(namePath, sT); setErr != nil || schema.Map[TestSectionID].Keys["name"].Val != "'Renamed'" {
+		t.Errorf("Failed to set %s: %v", namePath, setErr)
+	}
+
+	newPath := "/com/solus-project/budgie-panel/applets/{new}/position"
+
+	if setErr := schema.Set(newPath, sT); setErr != nil || !schema.Exists(newPath) || !schema.HasSection("applets/{new}") {
+		t.Errorf("Failed to set %s, creating its section: %v", newPath, setErr)
+	}
+
+	if setErr := schema.Set("/com/solus-project/budgie-panel/root-key", sT); setErr != nil || !schema.Map["/"].HasKey("root-key") {
+		t.Errorf("Failed to set a key in the root section: %v", setErr)
+	}
+
+	if deleteErr := schema.Delete(newPath); deleteErr != nil || schema.Exists(newPath) || schema.HasSection("applets/{new}") {
+		t.Errorf("Failed to delete %s, along with its now empty section: %v", newPath, deleteErr)
+	}
+
+	if deleteErr := schema.Delete(newPath); deleteErr != ErrKeyNotExists {
+		t.Errorf("Expected ErrKeyNotExists, got %v instead.", deleteErr)
+	}
+}
@@ -0,0 +1,166 @@
+/* layout.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// This file handles writing a losslessly parsed Schema back out
+// We keep every original line and only rewrite the lines for keys, and the sections, that have changed since parsing
+
+const (
+	layoutBlank   = iota // Empty (or whitespace only) line
+	layoutComment        // # comment
+	layoutSection        // [section] header
+	layoutKey            // key=val we parsed into our Schema
+	layoutOther          // Anything else, kept as-is
+)
+
+// schemaLayout is the original layout of a losslessly parsed keyfile
+type schemaLayout struct {
+	lines           []layoutLine
+	trailingNewline bool // Whether the content ended with a newline
+}
+
+// layoutLine is a single line of a losslessly parsed keyfile
+type layoutLine struct {
+	key     string      // Key, for layoutKey
+	keyText string      // Original text of the line up to and including the =, for layoutKey
+	kind    int         // One of our layout kinds
+	raw     string      // Original text of the line
+	section string      // Section, for layoutSection and layoutKey
+	value   *SchemaType // Copy of the value as it was parsed, for layoutKey
+}
+
+// losslessLines will render our Schema using its original layout
+// Unchanged keys keep their original text, changed keys are rewritten in place and deleted keys and sections are dropped,
+// sections along with the comments and blank lines leading up to them
+// New keys are added after the last key of their section, new sections are added at the end
+func (schema *Schema) losslessLines() []string {
+	layout := schema.layout
+	lines := []string{}
+	laidOut := make(map[string]map[string]bool) // Keys per section which are in our layout
+	insertAfter := make(map[string]int)         // Line index per section to add new keys after
+
+	for index, line := range layout.lines { // Determine where new keys for each section should go
+		switch line.kind {
+		case layoutSection:
+			insertAfter[line.section] = index
+
+			if laidOut[line.section] == nil {
+				laidOut[line.section] = make(map[string]bool)
+			}
+		case layoutKey:
+			insertAfter[line.section] = index
+			laidOut[line.section][line.key] = true
+		}
+	}
+
+	var currentKV *SchemaKV
+	owners := layout.lineSections()
+	dropped := false // Whether a section has been dropped since the last line we kept
+
+	for index, line := range layout.lines {
+		if line.kind == layoutSection {
+			currentKV = schema.Map[line.section]
+		}
+
+		if owner, owned := owners[index]; owned && schema.Map[owner] == nil { // Drop everything of a deleted section, including its leading comments
+			dropped = true
+			continue
+		}
+
+		if dropped && line.kind == layoutBlank && (len(lines) == 0 || lines[len(lines)-1] == "") { // Don't double up the blank lines around a dropped section
+			continue
+		}
+
+		dropped = false
+
+		if line.kind != layoutKey {
+			lines = append(lines, line.raw)
+		} else if sT, exists := currentKV.Keys[line.key]; exists { // Key has not since been deleted
+			if sT.Type == line.value.Type && sT.Matches(line.value) && sT.Val == line.value.Val { // Unchanged
+				lines = append(lines, line.raw)
+			} else {
+				lines = append(lines, line.keyText+sT.String())
+			}
+		}
+
+		if (line.kind == layoutSection || line.kind == layoutKey) && insertAfter[line.section] == index && currentKV != nil {
+			for _, key := range currentKV.sortedKeys() { // Add any new keys to the end of the section
+				if !laidOut[line.section][key] {
+					lines = append(lines, key+"="+currentKV.Keys[key].String())
+				}
+			}
+		}
+	}
+
+	for _, section := range schema.Order { // Add any new sections to the end
+		kv := schema.Map[section]
+
+		if _, exists := laidOut[section]; exists || kv == nil || len(kv.Keys) == 0 {
+			continue
+		}
+
+		if len(lines) != 0 && lines[len(lines)-1] != "" { // Separate from what came before
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, "["+section+"]")
+
+		for _, key := range kv.sortedKeys() {
+			lines = append(lines, key+"="+kv.Keys[key].String())
+		}
+
+		laidOut[section] = nil // Don't add it twice should it be in our Order twice
+	}
+
+	return lines
+}
+
+// lineSections will return the section each line belongs to, by index
+// Comments and blank lines directly before a section header belong to that section, since they usually describe it
+// Lines before the first section belong to none, so they are kept whatever happens to it
+func (layout *schemaLayout) lineSections() map[int]string {
+	owners := make(map[int]string)
+	current := ""
+
+	for index, line := range layout.lines { // Everything belongs to the section before it
+		if line.kind == layoutSection {
+			current = line.section
+		}
+
+		if current != "" {
+			owners[index] = current
+		}
+	}
+
+	following := "" // Section of the next header, while only comments and blank lines are between us and it
+
+	for index := len(layout.lines) - 1; index >= 0; index-- { // Then give leading comments and blank lines to the section after them
+		switch line := layout.lines[index]; line.kind {
+		case layoutSection:
+			following = line.section
+		case layoutBlank, layoutComment:
+			if _, owned := owners[index]; owned && following != "" {
+				owners[index] = following
+			}
+		default:
+			following = ""
+		}
+	}
+
+	return owners
+}
@@ -0,0 +1,102 @@
+/* layout_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestLosslessRoundTrip will test that an unmodified losslessly parsed Schema is reproduced exactly
+func TestLosslessRoundTrip(t *testing.T) {
+	for _, example := range []string{"examples/local.d__00-budgie-defaults", "examples/com__solus-project__budgie-panel"} {
+		content, _ := os.ReadFile(example)
+		schema, parseErr := NewSchemaWithOptions("/", content, ParseOptions{Lossless: true})
+
+		if parseErr != nil {
+			t.Fatalf("Failed to parse %s: %s", example, parseErr)
+		}
+
+		if str := schema.String(); str != string(content) {
+			t.Errorf("Lossless round trip of %s did not match, got:\n%s", example, str)
+		}
+	}
+}
+
+// TestLosslessEdits will test that edits to a losslessly parsed Schema only touch the affected lines
+func TestLosslessEdits(t *testing.T) {
+	content, _ := os.ReadFile("examples/local.d__00-budgie-defaults")
+	schema, _ := NewSchemaWithOptions("/", content, ParseOptions{Lossless: true})
+
+	panel, _ := schema.GetSection("com/solus-project/budgie-panel")
+	panel.ModifyKey("dark-theme", Modification{Value: "true"})
+
+	mouse, _ := schema.GetSection("org/gnome/desktop/peripherals/mouse")
+	mouse.DeleteKeys("accel-profile")
+	mouse.AddKey(ParseSchemaLine("natural-scroll=true"))
+
+	schema.DeleteSections("org/gnome/desktop/interface")
+	schema.AddSection("org/gnome/desktop/wm/preferences", &SchemaKV{Keys: map[string]*SchemaType{}})
+	wm, _ := schema.GetSection("org/gnome/desktop/wm/preferences")
+	wm.AddKey(ParseSchemaLine("button-layout='appmenu:close'"))
+
+	expected := `# Budgie defaults for our lab machines
+# Maintained by hand, please keep the comments
+
+[com/solus-project/budgie-panel]
+# Light theme for the lab
+dark-theme=true
+layout='solus-fortitude'
+
+[org/gnome/desktop/peripherals/mouse]
+speed=-0.63571428571428568
+natural-scroll=true
+
+[org/gnome/desktop/wm/preferences]
+button-layout='appmenu:close'
+`
+
+	if str := schema.String(); str != expected {
+		t.Errorf("Unexpected output after edits:\n%s\nExpected:\n%s", str, expected)
+	}
+
+	if str := schema.String(); !strings.Contains(str, "speed=-0.63571428571428568") { // Original value text retained
+		t.Error("Lossless output lost the original text of speed")
+	}
+}
+
+// TestLosslessDeleteSectionComments will test that the comments before a section are deleted with it, not with the section before them
+func TestLosslessDeleteSectionComments(t *testing.T) {
+	content := "# File header\n\n[a]\nx=1\n\n# About b\n[b]\ny=2\n\n# About c\n[c]\nz=3\n"
+
+	tests := map[string]string{
+		"a": "# File header\n\n# About b\n[b]\ny=2\n\n# About c\n[c]\nz=3\n",
+		"b": "# File header\n\n[a]\nx=1\n\n# About c\n[c]\nz=3\n",
+		"c": "# File header\n\n[a]\nx=1\n\n# About b\n[b]\ny=2\n",
+	}
+
+	for section, expected := range tests {
+		schema, _ := NewSchemaWithOptions("/", []byte(content), ParseOptions{Lossless: true})
+		schema.DeleteSections(section)
+
+		if str := schema.String(); str != expected {
+			t.Errorf("Unexpected output after deleting %s:\n%s\nExpected:\n%s", section, str, expected)
+		}
+	}
+}
@@ -35,6 +35,7 @@ func Bootstrap() {
 		os.Exit(1)
 	}
 
+	ExampleContent = content
 	TestSchema, _ = NewSchema("/com/solus-project/budgie-panel/", content) // Attempt to read our content
 }
 
@@ -43,6 +44,17 @@ func BootstrapSetKV(sectionID string) {
 	TestSchemaKV, _ = TestSchema.GetSection(sectionID) // Set our testing schema
 }
 
+// NewTestSchema will create a fresh Schema from our example content, for tests that should not touch TestSchema
+func NewTestSchema(t *testing.T) *Schema {
+	schema, readErr := NewSchema("/com/solus-project/budgie-panel/", ExampleContent)
+
+	if readErr != nil { // Failed to parse our example content
+		t.Fatalf("Failed to parse example content: %s", readErr)
+	}
+
+	return schema
+}
+
 func TestMain(m *testing.M) {
 	Bootstrap()
 	os.Exit(m.Run())
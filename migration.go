@@ -0,0 +1,278 @@
+/* migration.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// MigrationActionAddSection adds Section without any keys, failing if it already exists
+	MigrationActionAddSection = "add-section"
+
+	// MigrationActionDeleteKeys deletes Keys from Section
+	MigrationActionDeleteKeys = "delete-keys"
+
+	// MigrationActionDeleteSections deletes Sections, by prefix if Prefix is set
+	MigrationActionDeleteSections = "delete-sections"
+
+	// MigrationActionMigrateSections migrates sections from Source to Dest, see MigrateSectionsWithName
+	MigrationActionMigrateSections = "migrate-sections"
+
+	// MigrationActionMoveKey moves the Source key to Dest within Section
+	MigrationActionMoveKey = "move-key"
+
+	// MigrationActionOrderKeys orders the keys of Section to match Keys, with any keys not in Keys after them
+	MigrationActionOrderKeys = "order-keys"
+
+	// MigrationActionReplace performs the ReplaceValues replacement on Key within Section
+	MigrationActionReplace = "replace"
+
+	// MigrationActionSetKey sets Key within Section to Value, creating the section and key if needed
+	MigrationActionSetKey = "set-key"
+)
+
+// NewMigration will attempt to load a Migration from the provided TOML content
+// If the content is not valid or any of the steps are invalid, we will return an error
+func NewMigration(content []byte) (migration *Migration, loadErr error) {
+	if len(content) == 0 { // No content
+		loadErr = ErrNoContentProvided
+		return
+	}
+
+	var table tomlTable
+	if table, loadErr = parseTOML(content); loadErr != nil { // Failed to parse the TOML
+		return
+	}
+
+	migration = &Migration{}
+
+	if loadErr = decodeTOML(table, migration); loadErr != nil { // Failed to decode into our Migration
+		migration = nil
+		return
+	}
+
+	for index, step := range migration.Steps { // Validate each step now rather than halfway through applying
+		if loadErr = step.Validate(); loadErr != nil {
+			loadErr = fmt.Errorf("step %d: %w", index, loadErr)
+			migration = nil
+			return
+		}
+	}
+
+	return
+}
+
+// ApplyMigration will apply each step of the Migration to our Schema in order
+// A report is returned for every step we attempted. If a step fails, we stop and return its error
+func (schema *Schema) ApplyMigration(migration *Migration) (reports []MigrationStepReport, applyErr error) {
+	reports = []MigrationStepReport{}
+
+	for index, step := range migration.Steps {
+		report := MigrationStepReport{
+			Index:  index,
+			Action: step.Action,
+		}
+
+		report.Sections, report.Err = schema.applyMigrationStep(step)
+		reports = append(reports, report)
+
+		if report.Err != nil { // Step failed, don't continue on with a possibly unexpected Schema
+			applyErr = fmt.Errorf("step %d (%s): %w", index, step.Action, report.Err)
+			return
+		}
+	}
+
+	return
+}
+
+// Validate will check that the step has a known action and the fields that action requires
+func (step *MigrationStep) Validate() error {
+	var missing string
+
+	switch step.Action {
+	case MigrationActionAddSection:
+		if step.Section == "" {
+			missing = "section"
+		}
+	case MigrationActionDeleteKeys, MigrationActionOrderKeys:
+		if step.Section == "" || len(step.Keys) == 0 {
+			missing = "section and keys"
+		}
+	case MigrationActionDeleteSections:
+		if len(step.Sections) == 0 && step.Section == "" {
+			missing = "sections"
+		}
+	case MigrationActionMigrateSections:
+		if step.Source == "" || step.Dest == "" {
+			missing = "source and dest"
+		}
+	case MigrationActionMoveKey:
+		if step.Section == "" || step.Source == "" || step.Dest == "" {
+			missing = "section, source and dest"
+		}
+	case MigrationActionReplace:
+		if step.Section == "" || step.Key == "" || len(step.ReplaceValues) != 2 {
+			missing = "section, key and a replaceValue of two items"
+		}
+	case MigrationActionSetKey:
+		if step.Section == "" || step.Key == "" || step.Value == "" {
+			missing = "section, key and value"
+		}
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrMigrationStepInvalid, step.Action)
+	}
+
+	if missing != "" {
+		return fmt.Errorf("%w: %s requires %s", ErrMigrationStepInvalid, step.Action, missing)
+	}
+
+	return nil
+}
+
+// applyMigrationStep will apply a single step to our Schema, returning the sections it changed
+func (schema *Schema) applyMigrationStep(step MigrationStep) (sections []string, stepErr error) {
+	if stepErr = step.Validate(); stepErr != nil { // Steps may have been built in code rather than loaded
+		return
+	}
+
+	targets := schema.migrationStepTargets(step) // Determine what we are going to change for the report
+
+	switch step.Action {
+	case MigrationActionAddSection:
+		stepErr = schema.AddSection(step.Section, &SchemaKV{Order: []string{}, Keys: make(map[string]*SchemaType)})
+	case MigrationActionDeleteKeys:
+		var kv *SchemaKV
+		if kv, stepErr = schema.GetSection(step.Section); stepErr != nil {
+			return
+		}
+
+		kv.DeleteKeys(step.Keys...)
+	case MigrationActionDeleteSections:
+		if step.Prefix {
+			schema.DeleteSectionsWithPrefix(step.sections()...)
+		} else {
+			schema.DeleteSections(step.sections()...)
+		}
+	case MigrationActionMigrateSections:
+		stepErr = schema.MigrateSectionsWithName(step.Source, step.Dest, step.Exact)
+	case MigrationActionMoveKey:
+		var kv *SchemaKV
+		if kv, stepErr = schema.GetSection(step.Section); stepErr != nil {
+			return
+		}
+
+		stepErr = kv.MoveKey(step.Source, step.Dest)
+	case MigrationActionOrderKeys:
+		var kv *SchemaKV
+		if kv, stepErr = schema.GetSection(step.Section); stepErr != nil {
+			return
+		}
+
+		kv.orderKeys(step.Keys)
+	case MigrationActionReplace:
+		var kv *SchemaKV
+		if kv, stepErr = schema.GetSection(step.Section); stepErr != nil {
+			return
+		}
+
+		stepErr = kv.ModifyKey(step.Key, Modification{ReplaceValues: step.ReplaceValues})
+	case MigrationActionSetKey:
+		stepErr = schema.setKey(step.Section, step.Key, step.Value)
+	}
+
+	if stepErr == nil {
+		sections = targets
+	}
+
+	return
+}
+
+// migrationStepTargets will return the sorted names of the sections the step will change, before it is applied
+// For migrate-sections this includes both the source and destination sections
+func (schema *Schema) migrationStepTargets(step MigrationStep) (sections []string) {
+	switch step.Action {
+	case MigrationActionDeleteSections:
+		if step.Prefix { // Same as DeleteSectionsWithPrefix
+			sections = schema.sectionsWithPrefix(step.sections()...)
+			break
+		}
+
+		for _, section := range step.sections() {
+			if section != "/" { // Same as DeleteSections, the root section is not trimmed
+				section = TrimSectionSlashes(section)
+			}
+
+			if schema.HasSection(section) {
+				sections = append(sections, section)
+			}
+		}
+	case MigrationActionMigrateSections:
+		for _, pair := range schema.sectionsToMigrate(step.Source, step.Dest, step.Exact) {
+			sections = append(sections, pair[0], pair[1])
+		}
+	default: // Key operations only ever touch their own section
+		sections = []string{step.Section}
+	}
+
+	sort.Strings(sections)
+	return
+}
+
+// sections will return all the sections a delete-sections step refers to
+func (step *MigrationStep) sections() []string {
+	if step.Section == "" {
+		return step.Sections
+	}
+
+	return append([]string{step.Section}, step.Sections...)
+}
+
+// setKey will set the key in the section to the provided raw value, creating the section and key if needed
+func (schema *Schema) setKey(section string, key string, rawVal string) (setErr error) {
+	sT, parseErr := NewSchemaType(rawVal) // Parse first, so a bad value doesn't leave behind an empty section
+
+	if parseErr != nil {
+		setErr = parseErr
+		return
+	}
+
+	kv, getErr := schema.GetSection(section)
+
+	if getErr == nil && kv.HasKey(key) { // Already have this key, just modify it
+		return kv.ModifyKey(key, Modification{Value: rawVal})
+	}
+
+	if getErr != nil { // Section does not exist yet
+		if setErr = ValidateKeyName(key); setErr != nil { // Check before we add the section, which AddKey would leave empty
+			return
+		}
+
+		kv = &SchemaKV{
+			Order: []string{},
+			Keys:  make(map[string]*SchemaType),
+		}
+
+		if setErr = schema.AddSection(section, kv); setErr != nil {
+			return
+		}
+	}
+
+	return kv.AddKey(key, sT)
+}
@@ -0,0 +1,176 @@
+/* migrationRegistry.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	// DefaultMigrationLevelKey is the key we store the migration level in by default
+	DefaultMigrationLevelKey = "migration-level"
+
+	// DefaultMigrationLevelSection is the section the migration level key is in by default
+	DefaultMigrationLevelSection = "/"
+)
+
+// NewMigrationRegistry will create a new MigrationRegistry using the default level key and section
+func NewMigrationRegistry() *MigrationRegistry {
+	return &MigrationRegistry{
+		LevelKey:     DefaultMigrationLevelKey,
+		LevelSection: DefaultMigrationLevelSection,
+		Migrations:   make(map[int]*Migration),
+	}
+}
+
+// Register will register the Migration as the provided level
+// Levels start at 1, a Schema without a level key is considered to be at level 0
+func (registry *MigrationRegistry) Register(level int, migration *Migration) error {
+	if level < 1 { // Not a valid level
+		return ErrMigrationLevelInvalid
+	}
+
+	if _, exists := registry.Migrations[level]; exists { // Already have a migration for this level
+		return ErrMigrationLevelExists
+	}
+
+	registry.Migrations[level] = migration
+	return nil
+}
+
+// CurrentLevel will get the current migration level of the Schema
+// If the Schema has no level key, the level is 0
+func (registry *MigrationRegistry) CurrentLevel(schema *Schema) (level int, levelErr error) {
+	kv, getErr := schema.GetSection(registry.LevelSection)
+
+	if getErr != nil { // No section, so no level
+		return
+	}
+
+	sT, getErr := kv.GetVal(registry.LevelKey)
+
+	if getErr != nil { // No level key
+		return
+	}
+
+	switch sT.Type {
+	case "float64": // Plain integers are parsed as float64 by NewSchemaType
+		if sT.FloatVal != math.Trunc(sT.FloatVal) || sT.FloatHadTrailingZero { // Not a whole number
+			levelErr = fmt.Errorf("%w: %s is %s", ErrMigrationLevelInvalid, registry.LevelKey, sT.String())
+			return
+		}
+
+		level = int(sT.FloatVal)
+	case "int32":
+		level = int(sT.IntVal)
+	case "uint32":
+		level = int(sT.UintVal)
+	default:
+		levelErr = fmt.Errorf("%w: %s is %s", ErrMigrationLevelInvalid, registry.LevelKey, sT.String())
+		return
+	}
+
+	if level < 0 {
+		levelErr = fmt.Errorf("%w: %s is %d", ErrMigrationLevelInvalid, registry.LevelKey, level)
+	}
+
+	return
+}
+
+// Pending will return the levels of all registered migrations newer than the Schema's current level, in order
+func (registry *MigrationRegistry) Pending(schema *Schema) (levels []int, pendingErr error) {
+	var current int
+	if current, pendingErr = registry.CurrentLevel(schema); pendingErr != nil {
+		return
+	}
+
+	levels = []int{}
+
+	for level := range registry.Migrations {
+		if level > current { // Not yet applied
+			levels = append(levels, level)
+		}
+	}
+
+	sort.Ints(levels)
+	return
+}
+
+// Apply will apply every pending migration to the Schema in order, bumping the level after each one
+// Each migration is applied to a copy of the Schema, so a failing migration leaves the Schema as it was after the previous one
+// Running Apply again once everything is applied does nothing, so it is safe to call at every login
+func (registry *MigrationRegistry) Apply(schema *Schema) (applied []AppliedMigration, applyErr error) {
+	applied, _, applyErr = registry.ApplyWithUndo(schema)
+	return
+}
+
+// ApplyWithUndo will apply every pending migration like Apply, additionally returning a Migration which undoes all of them
+// The undo Migration includes restoring the level, so rolling back with it makes the migrations pending again
+func (registry *MigrationRegistry) ApplyWithUndo(schema *Schema) (applied []AppliedMigration, undo *Migration, applyErr error) {
+	var pending []int
+	if pending, applyErr = registry.Pending(schema); applyErr != nil {
+		return
+	}
+
+	applied = []AppliedMigration{}
+	undo = &Migration{
+		Description: "Undo migrations",
+		Steps:       []MigrationStep{},
+	}
+
+	for _, level := range pending {
+		working := schema.Clone() // Apply to a copy so we never leave a half-migrated Schema
+
+		reports, migrationUndo, migrateErr := working.ApplyMigrationWithUndo(registry.Migrations[level])
+
+		if migrateErr == nil { // Migration succeeded, bump our level
+			levelSnapshot := working.snapshotSections(registry.LevelSection)
+
+			if migrateErr = registry.setLevel(working, level); migrateErr == nil {
+				migrationUndo.Steps = append(working.undoSteps(levelSnapshot), migrationUndo.Steps...)
+			}
+		}
+
+		if migrateErr != nil {
+			applyErr = fmt.Errorf("migration %d: %w", level, migrateErr)
+			return
+		}
+
+		*schema = *working // Swap in our migrated Schema
+		applied = append(applied, AppliedMigration{Level: level, Reports: reports})
+		undo.Steps = append(migrationUndo.Steps, undo.Steps...) // Undo later migrations first
+	}
+
+	return
+}
+
+// setLevel will set the level key in the Schema, retaining a uint32 type if that is what the key already was
+func (registry *MigrationRegistry) setLevel(schema *Schema, level int) error {
+	rawVal := strconv.Itoa(level)
+
+	if kv, getErr := schema.GetSection(registry.LevelSection); getErr == nil {
+		if sT, getErr := kv.GetVal(registry.LevelKey); getErr == nil && sT.Type == "uint32" {
+			rawVal = "uint32 " + rawVal
+		}
+	}
+
+	return schema.setKey(registry.LevelSection, registry.LevelKey, rawVal)
+}
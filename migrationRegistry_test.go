@@ -0,0 +1,94 @@
+/* migrationRegistry_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestMigrationRegistryApply will test the MigrationRegistry's Apply
+func TestMigrationRegistryApply(t *testing.T) {
+	schema := NewTestSchema(t)
+	registry := NewMigrationRegistry()
+
+	registry.Register(1, &Migration{ // Already applied, example is at level 1
+		Steps: []MigrationStep{{Action: MigrationActionDeleteSections, Sections: []string{"panels"}, Prefix: true}},
+	})
+
+	registry.Register(2, &Migration{
+		Steps: []MigrationStep{{Action: MigrationActionSetKey, Section: "/", Key: "layout", Modification: Modification{Value: "'solus-redmond'"}}},
+	})
+
+	registry.Register(3, &Migration{
+		Steps: []MigrationStep{{Action: MigrationActionSetKey, Section: "/", Key: "dark-theme", Modification: Modification{Value: "false"}}},
+	})
+
+	if registerErr := registry.Register(3, &Migration{}); !errors.Is(registerErr, ErrMigrationLevelExists) {
+		t.Errorf("Expected ErrMigrationLevelExists, got %v instead.", registerErr)
+	}
+
+	applied, applyErr := registry.Apply(schema)
+
+	if applyErr != nil {
+		t.Fatalf("Failed to apply migrations: %s", applyErr)
+	}
+
+	if len(applied) != 2 || applied[0].Level != 2 || applied[1].Level != 3 { // Should only apply 2 and 3, in order
+		t.Errorf("Expected migrations 2 and 3 to be applied, got %v instead.", applied)
+	}
+
+	if !schema.HasSection("panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}") {
+		t.Error("Applied migration 1 even though the Schema was already at level 1")
+	}
+
+	if level, _ := registry.CurrentLevel(schema); level != 3 {
+		t.Errorf("Expected level 3 after applying, got %d instead.", level)
+	}
+
+	if applied, _ = registry.Apply(schema); len(applied) != 0 { // Should be idempotent
+		t.Errorf("Expected nothing to be applied a second time, got %v instead.", applied)
+	}
+}
+
+// TestMigrationRegistryAtomic will test that a failing migration leaves the Schema untouched
+func TestMigrationRegistryAtomic(t *testing.T) {
+	schema := NewTestSchema(t)
+	registry := NewMigrationRegistry()
+
+	registry.Register(2, &Migration{
+		Steps: []MigrationStep{
+			{Action: MigrationActionSetKey, Section: "/", Key: "dark-theme", Modification: Modification{Value: "false"}},
+			{Action: MigrationActionMoveKey, Section: "does/not/exist", Source: "a", Dest: "b"},
+		},
+	})
+
+	if _, applyErr := registry.Apply(schema); !errors.Is(applyErr, ErrSectionDoesNotExist) {
+		t.Errorf("Expected ErrSectionDoesNotExist, got %v instead.", applyErr)
+	}
+
+	root, _ := schema.GetSection("/")
+
+	if val, _ := root.GetVal("dark-theme"); !val.BoolVal {
+		t.Error("Failed migration modified dark-theme")
+	}
+
+	if level, _ := registry.CurrentLevel(schema); level != 1 {
+		t.Errorf("Expected level to remain 1, got %d instead.", level)
+	}
+}
@@ -0,0 +1,244 @@
+/* migrationUndo.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// Undo records are themselves Migrations, so they can be written to disk with Bytes and loaded again with NewMigration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sectionSnapshot is a copy of some sections of a Schema at a point in time, along with where they were in its Order
+type sectionSnapshot struct {
+	kvs   map[string]*SchemaKV // Copy of each section, nil if it did not exist
+	order map[string]int       // Index of each section which existed in the Order of the Schema
+}
+
+// ApplyMigrationWithUndo will apply the Migration like ApplyMigration, additionally returning a Migration which undoes it
+// The undo Migration is returned even if a step fails, covering every step that was attempted
+func (schema *Schema) ApplyMigrationWithUndo(migration *Migration) (reports []MigrationStepReport, undo *Migration, applyErr error) {
+	reports = []MigrationStepReport{}
+	undo = &Migration{
+		Description: "Undo " + migration.Description,
+		Steps:       []MigrationStep{},
+	}
+
+	for index, step := range migration.Steps {
+		report := MigrationStepReport{
+			Index:  index,
+			Action: step.Action,
+		}
+
+		var before sectionSnapshot
+		if step.Validate() == nil { // Only snapshot steps we can work out the targets for
+			before = schema.snapshotSections(schema.migrationStepTargets(step)...)
+		}
+
+		report.Sections, report.Err = schema.applyMigrationStep(step)
+		reports = append(reports, report)
+
+		inverse := schema.undoSteps(before)
+
+		if step.Action == MigrationActionMigrateSections { // Record renames as renames where we can
+			inverse = schema.undoRenames(before, step, inverse)
+		}
+
+		undo.Steps = append(inverse, undo.Steps...) // Undo in reverse order
+
+		if report.Err != nil { // Step failed, don't continue on with a possibly unexpected Schema
+			applyErr = fmt.Errorf("step %d (%s): %w", index, step.Action, report.Err)
+			return
+		}
+	}
+
+	return
+}
+
+// Rollback will apply the provided undo Migration to our Schema
+// Like a MigrationRegistry, this is done on a copy so a failure leaves the Schema untouched
+func (schema *Schema) Rollback(undo *Migration) (rollbackErr error) {
+	working := schema.Clone()
+
+	if _, rollbackErr = working.ApplyMigration(undo); rollbackErr != nil {
+		rollbackErr = fmt.Errorf("failed to roll back: %w", rollbackErr)
+		return
+	}
+
+	*schema = *working // Swap in our rolled back Schema
+	return
+}
+
+// Bytes will encode the Migration as TOML, which can be loaded again with NewMigration
+func (migration *Migration) Bytes() []byte {
+	return encodeTOML(migration)
+}
+
+// snapshotSections will take a copy of the provided sections as they currently are
+func (schema *Schema) snapshotSections(sections ...string) sectionSnapshot {
+	snapshot := sectionSnapshot{
+		kvs:   make(map[string]*SchemaKV),
+		order: make(map[string]int),
+	}
+
+	for _, section := range sections {
+		snapshot.kvs[section] = nil
+
+		if kv, exists := schema.Map[section]; exists {
+			snapshot.kvs[section] = kv.Duplicate()
+			snapshot.order[section] = len(schema.Order) // Appended if it is somehow not in our Order
+		}
+	}
+
+	for index, section := range schema.Order {
+		if _, snapshotted := snapshot.order[section]; snapshotted {
+			snapshot.order[section] = index
+		}
+	}
+
+	return snapshot
+}
+
+// undoSteps will return the steps needed to return the sections in the snapshot to their snapshotted state
+func (schema *Schema) undoSteps(snapshot sectionSnapshot) (steps []MigrationStep) {
+	steps = []MigrationStep{}
+	sections := []string{}
+
+	for section := range snapshot.kvs {
+		sections = append(sections, section)
+	}
+
+	sort.Strings(sections)
+
+	for _, section := range sections {
+		before := snapshot.kvs[section]
+		after, exists := schema.Map[section]
+
+		if before == nil { // Section did not exist before
+			if exists {
+				steps = append(steps, MigrationStep{Action: MigrationActionDeleteSections, Sections: []string{section}})
+			}
+
+			continue
+		}
+
+		if !exists { // Recreate the section, so it exists even if it had no keys
+			steps = append(steps, MigrationStep{Action: MigrationActionAddSection, Section: section})
+		}
+
+		added := []string{}
+		order := []string{} // Order of the keys once the steps so far are undone
+
+		if exists { // Remove any keys which did not exist before
+			for _, key := range after.Order {
+				if !before.HasKey(key) {
+					added = append(added, key)
+				} else {
+					order = append(order, key)
+				}
+			}
+		}
+
+		if len(added) != 0 {
+			steps = append(steps, MigrationStep{Action: MigrationActionDeleteKeys, Section: section, Keys: added})
+		}
+
+		for _, key := range before.Order { // Restore any keys which were removed or changed
+			oldSt := before.Keys[key]
+
+			if !exists || !after.HasKey(key) { // Setting it adds it, which sorts the keys like AddKey
+				order = append(order, key)
+				sort.Strings(order)
+			}
+
+			if exists {
+				if newSt, hasKey := after.Keys[key]; hasKey && newSt.Matches(oldSt) && newSt.Val == oldSt.Val { // Unchanged
+					continue
+				}
+			}
+
+			steps = append(steps, MigrationStep{
+				Action:       MigrationActionSetKey,
+				Section:      section,
+				Key:          key,
+				Modification: Modification{Value: oldSt.rawValue()},
+			})
+		}
+
+		if strings.Join(order, "\x00") != strings.Join(before.Order, "\x00") { // Keys would be out of their original order
+			steps = append(steps, MigrationStep{Action: MigrationActionOrderKeys, Section: section, Keys: before.Order})
+		}
+	}
+
+	return
+}
+
+// undoRenames will replace the inverse steps for sections a migrate-sections step cleanly renamed with a rename back
+func (schema *Schema) undoRenames(before sectionSnapshot, step MigrationStep, inverse []MigrationStep) []MigrationStep {
+	renamed := make(map[string]bool)
+	renames := []MigrationStep{}
+
+	for _, pair := range before.migrationPairs(step) {
+		source, dest := pair[0], pair[1]
+		oldKv, newKv := before.kvs[source], schema.Map[dest]
+
+		if oldKv == nil || newKv == nil || before.kvs[dest] != nil || schema.HasSection(source) { // Not a clean rename
+			continue
+		}
+
+		if !oldKv.Equal(newKv) { // Content changed along the way
+			continue
+		}
+
+		renamed[source], renamed[dest] = true, true
+		renames = append(renames, MigrationStep{Action: MigrationActionMigrateSections, Source: dest, Dest: source, Exact: true})
+	}
+
+	steps := []MigrationStep{}
+
+	for _, inverseStep := range inverse { // Drop anything the renames already take care of
+		if !renamed[inverseStep.Section] && !(len(inverseStep.Sections) == 1 && renamed[inverseStep.Sections[0]]) {
+			steps = append(steps, inverseStep)
+		}
+	}
+
+	return append(renames, steps...)
+}
+
+// migrationPairs will return the source and destination pairs a migrate-sections step applied to, based on the snapshot
+func (snapshot sectionSnapshot) migrationPairs(step MigrationStep) [][2]string {
+	existing := &Schema{Map: make(map[string]*SchemaKV)}
+
+	for section, kv := range snapshot.kvs {
+		if kv != nil {
+			existing.Map[section] = kv
+		}
+	}
+
+	return existing.sectionsToMigrate(step.Source, step.Dest, step.Exact)
+}
+
+// rawValue will return text for the SchemaType which NewSchemaType parses back into a matching SchemaType
+func (sT *SchemaType) rawValue() string {
+	if parsed, parseErr := NewSchemaType(sT.Val); parseErr == nil && parsed.Matches(sT) { // Val is still accurate
+		return sT.Val
+	}
+
+	return sT.String()
+}
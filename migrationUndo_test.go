@@ -0,0 +1,130 @@
+/* migrationUndo_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestRollback will test ApplyMigrationWithUndo and Rollback, including a round trip of the undo record through TOML
+func TestRollback(t *testing.T) {
+	original := NewTestSchema(t)
+	schema := NewTestSchema(t)
+
+	content, _ := os.ReadFile("examples/budgie-panel-migration.toml")
+	migration, _ := NewMigration(content)
+
+	_, undo, applyErr := schema.ApplyMigrationWithUndo(migration)
+
+	if applyErr != nil {
+		t.Fatalf("Failed to apply example migration: %s", applyErr)
+	}
+
+	if undo.Steps[len(undo.Steps)-1].Action != MigrationActionMigrateSections { // Rename should be undone last, as a rename
+		t.Errorf("Expected the migrate-sections step to be undone with a rename, got %v instead.", undo.Steps[len(undo.Steps)-1])
+	}
+
+	loadedUndo, loadErr := NewMigration(undo.Bytes()) // Round trip our undo record
+
+	if loadErr != nil {
+		t.Fatalf("Failed to load serialised undo record: %s\n%s", loadErr, undo.Bytes())
+	}
+
+	if rollbackErr := schema.Rollback(loadedUndo); rollbackErr != nil {
+		t.Fatalf("Failed to roll back: %s", rollbackErr)
+	}
+
+	if len(schema.Map) != len(original.Map) {
+		t.Errorf("Expected %d sections after rolling back, got %d instead.", len(original.Map), len(schema.Map))
+	}
+
+	for section, kv := range original.Map { // Every section should be back the way it was
+		if rolledBack, exists := schema.Map[section]; !exists || !rolledBack.Equal(kv) {
+			t.Errorf("Section %s was not restored by rolling back", section)
+		}
+	}
+}
+
+// TestRollbackEmptySectionAndOrder will test that rolling back recreates sections without keys and restores key order
+func TestRollbackEmptySectionAndOrder(t *testing.T) {
+	schema, _ := NewSchema("/org/example/", []byte("[a]\nzeta=1\nalpha=2\nmid=3\n"))
+	schema.Map["a"].Order = []string{"zeta", "alpha", "mid"} // Unsorted, like a lossless parse of a hand-written file
+	schema.AddSection("empty", &SchemaKV{Order: []string{}, Keys: make(map[string]*SchemaType)})
+
+	migration := &Migration{
+		Steps: []MigrationStep{
+			{Action: MigrationActionDeleteSections, Sections: []string{"empty"}},
+			{Action: MigrationActionMoveKey, Section: "a", Source: "zeta", Dest: "renamed"},
+			{Action: MigrationActionDeleteKeys, Section: "a", Keys: []string{"alpha"}},
+		},
+	}
+
+	_, undo, applyErr := schema.ApplyMigrationWithUndo(migration)
+
+	if applyErr != nil {
+		t.Fatalf("Failed to apply: %s", applyErr)
+	}
+
+	loadedUndo, loadErr := NewMigration(undo.Bytes())
+
+	if loadErr != nil {
+		t.Fatalf("Failed to load serialised undo record: %s\n%s", loadErr, undo.Bytes())
+	}
+
+	if rollbackErr := schema.Rollback(loadedUndo); rollbackErr != nil {
+		t.Fatalf("Failed to roll back: %s", rollbackErr)
+	}
+
+	if !schema.HasSection("empty") {
+		t.Error("Expected the empty section to be recreated")
+	}
+
+	if kv, _ := schema.GetSection("a"); strings.Join(kv.Order, " ") != "zeta alpha mid" {
+		t.Errorf("Expected the keys of a to be back in order, got %v", kv.Order)
+	}
+}
+
+// TestMigrationRegistryApplyWithUndo will test that undoing registry migrations restores the level
+func TestMigrationRegistryApplyWithUndo(t *testing.T) {
+	schema := NewTestSchema(t)
+	registry := NewMigrationRegistry()
+
+	registry.Register(2, &Migration{
+		Steps: []MigrationStep{{Action: MigrationActionSetKey, Section: "/", Key: "added", Modification: Modification{Value: "'yes'"}}},
+	})
+
+	_, undo, applyErr := registry.ApplyWithUndo(schema)
+
+	if applyErr != nil {
+		t.Fatalf("Failed to apply migrations: %s", applyErr)
+	}
+
+	if rollbackErr := schema.Rollback(undo); rollbackErr != nil {
+		t.Fatalf("Failed to roll back: %s", rollbackErr)
+	}
+
+	if level, _ := registry.CurrentLevel(schema); level != 1 {
+		t.Errorf("Expected level 1 after rolling back, got %d instead.", level)
+	}
+
+	if root, _ := schema.GetSection("/"); root.HasKey("added") {
+		t.Error("Rolling back did not remove the added key")
+	}
+}
@@ -0,0 +1,155 @@
+/* migration_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// TestNewMigration will test NewMigration
+func TestNewMigration(t *testing.T) {
+	content, readErr := os.ReadFile("examples/budgie-panel-migration.toml")
+
+	if readErr != nil {
+		t.Fatalf("Failed to read example migration: %s", readErr)
+	}
+
+	migration, loadErr := NewMigration(content)
+
+	if loadErr != nil { // Failed to load our migration
+		t.Fatalf("Failed to load example migration: %s", loadErr)
+	}
+
+	if len(migration.Steps) != 5 {
+		t.Fatalf("Expected 5 steps, got %d instead.", len(migration.Steps))
+	}
+
+	if migration.Steps[4].ReplaceValues[1] != "'Date and Time'" { // Embedded Modification not decoded
+		t.Errorf("Failed to decode replaceValue into the step's Modification, got %v", migration.Steps[4].ReplaceValues)
+	}
+
+	if _, loadErr = NewMigration([]byte("[[step]]\naction = \"explode\"\n")); !errors.Is(loadErr, ErrMigrationStepInvalid) {
+		t.Errorf("Expected ErrMigrationStepInvalid for an unknown action, got %v instead.", loadErr)
+	}
+
+	if _, loadErr = NewMigration([]byte("[[step]]\nactoin = \"set-key\"\n")); !errors.Is(loadErr, ErrTOMLSyntax) {
+		t.Errorf("Expected ErrTOMLSyntax for an unknown key, got %v instead.", loadErr)
+	}
+}
+
+// TestApplyMigration will test ApplyMigration
+func TestApplyMigration(t *testing.T) {
+	schema := NewTestSchema(t)
+	content, _ := os.ReadFile("examples/budgie-panel-migration.toml")
+	migration, _ := NewMigration(content)
+
+	reports, applyErr := schema.ApplyMigration(migration)
+
+	if applyErr != nil {
+		t.Fatalf("Failed to apply example migration: %s", applyErr)
+	}
+
+	if len(reports) != len(migration.Steps) { // Should have a report per step
+		t.Errorf("Expected %d reports, got %d instead.", len(migration.Steps), len(reports))
+	}
+
+	if !schema.HasSection("moved-panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}") {
+		t.Error("Failed to migrate panels to moved-panels")
+	}
+
+	if schema.HasSection("instance/tray/{feece066-1f25-11eb-a797-e0d55e200f1c}") {
+		t.Error("Failed to delete instance sections by prefix")
+	}
+
+	tasklist, _ := schema.GetSection("applets/{8bbd5acc-0dae-11eb-ad1d-e0d55e200f1c}")
+
+	if !tasklist.HasKey("index") || tasklist.HasKey("position") {
+		t.Error("Failed to move position to index")
+	}
+
+	root, _ := schema.GetSection("/")
+
+	if val, _ := root.GetVal("dark-theme"); val.BoolVal {
+		t.Error("Failed to set dark-theme to false")
+	}
+
+	clock, _ := schema.GetSection("applets/{8bc94562-0dae-11eb-ad1d-e0d55e200f1c}")
+
+	if val, _ := clock.GetVal("name"); val.Val != "'Date and Time'" {
+		t.Errorf("Failed to replace the Clock name, got %s instead.", val.Val)
+	}
+}
+
+// TestApplyMigrationFailure will test that ApplyMigration stops at the failing step
+func TestApplyMigrationFailure(t *testing.T) {
+	schema := NewTestSchema(t)
+	migration := &Migration{
+		Steps: []MigrationStep{
+			{Action: MigrationActionMoveKey, Section: "does/not/exist", Source: "a", Dest: "b"},
+			{Action: MigrationActionSetKey, Section: "/", Key: "dark-theme", Modification: Modification{Value: "false"}},
+		},
+	}
+
+	reports, applyErr := schema.ApplyMigration(migration)
+
+	if !errors.Is(applyErr, ErrSectionDoesNotExist) {
+		t.Errorf("Expected ErrSectionDoesNotExist, got %v instead.", applyErr)
+	}
+
+	if len(reports) != 1 { // Should have stopped at the first step
+		t.Errorf("Expected 1 report, got %d instead.", len(reports))
+	}
+}
+
+// TestApplyMigrationSectionExists will test that a migrate-sections step onto an existing section fails, leaving the source
+func TestApplyMigrationSectionExists(t *testing.T) {
+	schema := NewTestSchema(t)
+	panel := "panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}"
+	migration, _ := NewMigration([]byte("[[step]]\naction = \"migrate-sections\"\nsource = \"" + panel + "\"\ndest = \"applets/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}\"\nexact = true\n"))
+
+	reports, applyErr := schema.ApplyMigration(migration)
+
+	if !errors.Is(applyErr, ErrSectionExists) || len(reports) != 1 || !errors.Is(reports[0].Err, ErrSectionExists) {
+		t.Errorf("Expected ErrSectionExists in the report, got %v and %+v instead.", applyErr, reports)
+	}
+
+	if !schema.HasSection(panel) || len(reports[0].Sections) != 0 {
+		t.Errorf("Expected %s to be left in place, with no sections reported as changed", panel)
+	}
+}
+
+// TestApplyMigrationSetKeyInvalid will test that a set-key step with a bad value or key name doesn't leave an empty section
+func TestApplyMigrationSetKeyInvalid(t *testing.T) {
+	schema := NewTestSchema(t)
+	steps := []MigrationStep{
+		{Action: MigrationActionSetKey, Section: "new-section", Key: "count", Modification: Modification{Value: "uint32 abc"}},
+		{Action: MigrationActionSetKey, Section: "new-section", Key: "bad/name", Modification: Modification{Value: "true"}},
+	}
+
+	for _, step := range steps {
+		if _, applyErr := schema.ApplyMigration(&Migration{Steps: []MigrationStep{step}}); applyErr == nil {
+			t.Errorf("Expected an error setting %s to %s", step.Key, step.Value)
+		}
+
+		if schema.HasSection("new-section") {
+			t.Errorf("Expected no section to be added setting %s to %s", step.Key, step.Value)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+/* names.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// Section and key names follow the GKeyFile grammar for group and key names,
+// further restricted by dconf, which turns every section and key into a path (like /section/key)
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateSectionName will check that the section name is a valid GKeyFile group name that dconf will accept
+// A section is either / (the root of the Schema's path) or a relative path like applets/{uuid}
+func ValidateSectionName(section string) error {
+	if section == "/" { // The root
+		return nil
+	}
+
+	problem := ""
+
+	switch {
+	case section == "":
+		problem = "is empty"
+	case !utf8.ValidString(section):
+		problem = "is not valid UTF-8"
+	case strings.ContainsAny(section, "[]"):
+		problem = "contains [ or ]"
+	case containsControl(section):
+		problem = "contains a control character"
+	case strings.HasPrefix(section, "/"):
+		problem = "starts with /"
+	case strings.HasSuffix(section, "/"):
+		problem = "ends with /"
+	case strings.Contains(section, "//"):
+		problem = "contains //"
+	}
+
+	if problem != "" {
+		return fmt.Errorf("%w: %q %s", ErrInvalidSectionName, section, problem)
+	}
+
+	return nil
+}
+
+// ValidateKeyName will check that the key name is a valid GKeyFile key name that dconf will accept
+func ValidateKeyName(key string) error {
+	problem := ""
+
+	switch {
+	case key == "":
+		problem = "is empty"
+	case !utf8.ValidString(key):
+		problem = "is not valid UTF-8"
+	case strings.TrimSpace(key) != key:
+		problem = "starts or ends with whitespace"
+	case strings.ContainsAny(key, "=[]"): // dconf has no support for GKeyFile's locale suffixes
+		problem = "contains =, [ or ]"
+	case containsControl(key):
+		problem = "contains a control character"
+	case strings.Contains(key, "/"):
+		problem = "contains /"
+	}
+
+	if problem != "" {
+		return fmt.Errorf("%w: %q %s", ErrInvalidKeyName, key, problem)
+	}
+
+	return nil
+}
+
+// parseSectionHeader will parse a GKeyFile group line like [section], returning the section name
+// As with GKeyFile, whitespace is permitted before the [ and after the ], but nothing else
+func parseSectionHeader(line string) (section string, isHeader bool) {
+	line = strings.TrimLeft(line, " \t")
+
+	if !strings.HasPrefix(line, "[") {
+		return
+	}
+
+	end := strings.IndexByte(line, ']')
+
+	if end == -1 || strings.Trim(line[end+1:], " \t") != "" { // Not terminated, or something after the ]
+		return
+	}
+
+	return line[1:end], true
+}
+
+// containsControl will check if the string contains any ASCII control characters
+func containsControl(str string) bool {
+	for index := 0; index < len(str); index++ {
+		if str[index] < 0x20 || str[index] == 0x7f {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,129 @@
+/* names_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestValidateSectionName will test ValidateSectionName against valid and invalid section names
+func TestValidateSectionName(t *testing.T) {
+	valid := []string{"/", "applets", "applets/{5f6ed400-5f5f-11eb-a6f2-0242ac130003}", "user@host", "a+b/c~d", "with space", "défaut/日本"}
+	invalid := []string{"", "/applets", "applets/", "applets//clock", "app[lets]", "new\nline", "bad\xff"}
+
+	for _, section := range valid {
+		if validateErr := ValidateSectionName(section); validateErr != nil {
+			t.Errorf("Expected %q to be valid, got %s instead.", section, validateErr)
+		}
+	}
+
+	for _, section := range invalid {
+		if validateErr := ValidateSectionName(section); !errors.Is(validateErr, ErrInvalidSectionName) {
+			t.Errorf("Expected %q to be invalid, got %v instead.", section, validateErr)
+		}
+	}
+}
+
+// TestValidateKeyName will test ValidateKeyName against valid and invalid key names
+func TestValidateKeyName(t *testing.T) {
+	valid := []string{"name", "panel-size", "with space", "émoji-ünicode"}
+	invalid := []string{"", " name", "name ", "name[en]", "a/b", "tab\tkey"}
+
+	for _, key := range valid {
+		if validateErr := ValidateKeyName(key); validateErr != nil {
+			t.Errorf("Expected %q to be valid, got %s instead.", key, validateErr)
+		}
+	}
+
+	for _, key := range invalid {
+		if validateErr := ValidateKeyName(key); !errors.Is(validateErr, ErrInvalidKeyName) {
+			t.Errorf("Expected %q to be invalid, got %v instead.", key, validateErr)
+		}
+	}
+}
+
+// TestParseSectionNames will test that we parse section and key names dconf accepts, and skip those it rejects
+func TestParseSectionNames(t *testing.T) {
+	content := []byte(`[user@host/a+b~c]
+  name = 'Clock'
+
+[espaço/日本]
+key='value'
+
+[applets//clock]
+name='Skipped'
+
+[applets/]
+name='Skipped'
+
+[good]
+bad/key=true
+good=true
+`)
+
+	schema, parseErr := NewSchemaWithOptions("/", content, ParseOptions{})
+
+	if parseErr != nil {
+		t.Fatalf("Failed to parse: %s", parseErr)
+	}
+
+	if len(schema.Order) != 3 {
+		t.Fatalf("Expected 3 sections, got %v instead.", schema.Order)
+	}
+
+	if kv, _ := schema.GetSection("user@host/a+b~c"); kv == nil || !kv.HasKey("name") || kv.Keys["name"].Val != "'Clock'" {
+		t.Errorf("Whitespace around = was not ignored: %v", kv)
+	}
+
+	if !schema.HasSection("espaço/日本") {
+		t.Error("Non-ASCII section was not parsed")
+	}
+
+	expected := []error{ErrInvalidSectionName, ErrInvalidSectionName, ErrInvalidKeyName}
+
+	if len(schema.Warnings) != len(expected) {
+		t.Fatalf("Expected %d warnings, got %d instead:\n%s", len(expected), len(schema.Warnings), schema.Warnings)
+	}
+
+	for index, warning := range schema.Warnings {
+		if !errors.Is(warning, expected[index]) {
+			t.Errorf("Expected warning %d to be %v, got %v instead.", index, expected[index], warning)
+		}
+	}
+
+	if kv, _ := schema.GetSection("good"); kv.HasKey("bad/key") || !kv.HasKey("good") {
+		t.Errorf("Unexpected keys in good: %v", kv.Keys)
+	}
+}
+
+// TestAddInvalidNames will test that AddSection and AddKey reject invalid names
+func TestAddInvalidNames(t *testing.T) {
+	schema := NewTestSchema(t)
+
+	if addErr := schema.AddSection("applets//clock", &SchemaKV{Keys: make(map[string]*SchemaType)}); !errors.Is(addErr, ErrInvalidSectionName) {
+		t.Errorf("Expected ErrInvalidSectionName, got %v instead.", addErr)
+	}
+
+	kv := &SchemaKV{Keys: make(map[string]*SchemaType)}
+	sT, _ := NewSchemaType("true")
+
+	if addErr := kv.AddKey("show/hide", sT); !errors.Is(addErr, ErrInvalidKeyName) {
+		t.Errorf("Expected ErrInvalidKeyName, got %v instead.", addErr)
+	}
+}
@@ -0,0 +1,438 @@
+/* parser.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// DuplicatePolicyError fails parsing on the first duplicate section or key, regardless of Strict
+	DuplicatePolicyError = "error"
+
+	// DuplicatePolicyFirstWins keeps the first of any duplicate section or key, skipping later ones
+	DuplicatePolicyFirstWins = "first-wins"
+
+	// DuplicatePolicyLastWins replaces an earlier section or key with any later duplicate of it
+	DuplicatePolicyLastWins = "last-wins"
+
+	// DuplicatePolicyMerge merges duplicate sections, with later keys replacing earlier ones
+	DuplicatePolicyMerge = "merge"
+)
+
+// schemaParser holds the state of parsing a keyfile into a Schema, one line at a time
+type schemaParser struct {
+	currentKV      *SchemaKV                 // Key value store of the section we are in, nil before the first section
+	currentSection string                    // Section we are in
+	keyLines       map[string]map[string]int // Line each key of each section first appeared on
+	line           int                       // Line number we are on
+	opts           ParseOptions
+	schema         *Schema
+	sectionLines   map[string]int // Line each section first appeared on
+	skipping       bool           // Whether we are skipping the keys of an invalid or duplicate section
+}
+
+// NewSchemaWithOptions will attempt to create a new Schema from the provided content, parsed per the provided ParseOptions
+// When parsing strictly, any problem with the content fails parsing with ParseErrors
+func NewSchemaWithOptions(path string, content []byte, opts ParseOptions) (schema *Schema, readErr error) {
+	if len(content) == 0 { // content not specified or has no content
+		readErr = ErrNoContentProvided
+		return
+	}
+
+	return NewSchemaFromReader(path, bytes.NewReader(content), opts)
+}
+
+// NewSchemaFromReader will attempt to create a new Schema by incrementally parsing the content of the reader
+// Only a line at a time is held in memory, use the limits in ParseOptions when parsing untrusted content
+func NewSchemaFromReader(path string, r io.Reader, opts ParseOptions) (schema *Schema, readErr error) {
+	switch opts.Duplicates {
+	case "", DuplicatePolicyError, DuplicatePolicyFirstWins, DuplicatePolicyLastWins, DuplicatePolicyMerge:
+	default: // Don't fall back to our default for a misspelled policy
+		readErr = fmt.Errorf("%w: %q", ErrInvalidDuplicatePolicy, opts.Duplicates)
+		return
+	}
+
+	parser := newSchemaParser(path, opts)
+	reader := bufio.NewReader(r)
+	line := []byte{}
+	read := false // Whether we have read any content
+
+	for {
+		chunk, chunkErr := reader.ReadSlice('\n') // Read up to the end of the line, or as much as we can buffer
+		line = append(line, chunk...)
+		read = read || len(chunk) != 0
+
+		if opts.MaxLineLength > 0 && len(bytes.TrimSuffix(line, []byte("\n"))) > opts.MaxLineLength { // Don't keep reading an overly long line
+			parser.line++
+			readErr = parser.fatal(1, ErrLineTooLong)
+			return
+		}
+
+		if chunkErr == bufio.ErrBufferFull { // Line is longer than our buffer, keep going
+			continue
+		}
+
+		if chunkErr != nil && chunkErr != io.EOF { // Failed to read
+			readErr = chunkErr
+			return
+		}
+
+		if chunkErr == io.EOF && len(line) == 0 { // Content ended with a newline, which is not a line of its own
+			if parser.line != 0 {
+				parser.trailingNewline()
+			}
+
+			break
+		}
+
+		if readErr = parser.parseLine(string(bytes.TrimSuffix(line, []byte("\n")))); readErr != nil { // Exceeded a limit
+			return
+		}
+
+		if chunkErr == io.EOF { // Last line had no newline
+			break
+		}
+
+		line = line[:0]
+	}
+
+	if !read { // Nothing to parse
+		readErr = ErrNoContentProvided
+		return
+	}
+
+	return parser.finish()
+}
+
+// newSchemaParser will create a new schemaParser for a Schema with the provided path
+func newSchemaParser(path string, opts ParseOptions) *schemaParser {
+	parser := &schemaParser{
+		keyLines:     make(map[string]map[string]int),
+		opts:         opts,
+		sectionLines: make(map[string]int),
+		schema: &Schema{
+			Map:   make(map[string]*SchemaKV),
+			Order: []string{},
+			Path:  path,
+		},
+	}
+
+	if opts.Lossless { // Keep track of every line so we can write them back out
+		parser.schema.layout = &schemaLayout{lines: []layoutLine{}}
+	}
+
+	return parser
+}
+
+// parseLine will parse a single line of our keyfile
+// Sections last until the next section header, as with GKeyFile. Blank lines and # comments are ignored
+// Only exceeding one of the limits in our ParseOptions returns an error, anything else is recorded as a warning
+func (parser *schemaParser) parseLine(line string) (parseErr error) {
+	parser.line++
+	trimmed := strings.TrimSpace(line)
+
+	switch {
+	case trimmed == "":
+		parser.addLayoutLine(layoutLine{kind: layoutBlank, raw: line})
+	case strings.HasPrefix(trimmed, "#"):
+		parser.addLayoutLine(layoutLine{kind: layoutComment, raw: line})
+	default:
+		section, isHeader := parseSectionHeader(line)
+
+		if !isHeader {
+			parseErr = parser.parseKey(line)
+			break
+		}
+
+		if validateErr := ValidateSectionName(section); validateErr != nil { // dconf would reject this section
+			parser.warn(2, section, "", validateErr)
+			parser.addLayoutLine(layoutLine{kind: layoutOther, raw: line})
+			parser.currentSection = section
+			parser.currentKV = nil
+			parser.skipping = true // Skip its keys rather than adding them to whatever section came before
+			break
+		}
+
+		if parseErr = parser.parseSection(section); parseErr == nil {
+			parser.addLayoutLine(layoutLine{kind: layoutSection, raw: line, section: section})
+		}
+	}
+
+	return
+}
+
+// parseSection will switch us over to the provided section
+func (parser *schemaParser) parseSection(section string) error {
+	parser.currentSection = section
+	parser.skipping = false
+
+	if _, exists := parser.schema.Map[section]; exists { // Already have this section
+		return parser.duplicateSection(section)
+	}
+
+	if parser.opts.MaxSections > 0 && len(parser.schema.Map) >= parser.opts.MaxSections { // One section too many
+		return parser.fatal(1, ErrTooManySections)
+	}
+
+	parser.currentKV = &SchemaKV{
+		Order: []string{},
+		Keys:  make(map[string]*SchemaType),
+	}
+
+	parser.schema.Map[section] = parser.currentKV
+	parser.schema.Order = append(parser.schema.Order, section) // Add our section
+	parser.sectionLines[section] = parser.line
+	parser.keyLines[section] = make(map[string]int)
+	return nil
+}
+
+// duplicateSection will resolve a section we already have per our DuplicatePolicy
+func (parser *schemaParser) duplicateSection(section string) error {
+	resolution := parser.opts.Duplicates
+
+	switch resolution {
+	case DuplicatePolicyError:
+		return parser.fatal(1, ErrSectionExists)
+	case DuplicatePolicyFirstWins:
+		parser.currentKV = nil
+		parser.skipping = true // Skip its keys, the earlier section wins
+	case DuplicatePolicyLastWins:
+		parser.currentKV = &SchemaKV{
+			Order: []string{},
+			Keys:  make(map[string]*SchemaType),
+		}
+
+		parser.schema.Map[section] = parser.currentKV // Replace the earlier section, keeping its place in our Order
+		parser.keyLines[section] = make(map[string]int)
+		parser.supersedeLayout(section, "")
+	default: // Merge, warning about it unless merging was asked for
+		if resolution != DuplicatePolicyMerge {
+			parser.warn(1, section, "", ErrSectionExists)
+			resolution = DuplicatePolicyMerge
+		}
+
+		parser.currentKV = parser.schema.Map[section]
+	}
+
+	parser.decide(section, "", parser.sectionLines[section], resolution)
+	return nil
+}
+
+// duplicateKey will resolve a key we already have in our current section per our DuplicatePolicy
+func (parser *schemaParser) duplicateKey(key string, sT *SchemaType) (superseded bool, parseErr error) {
+	resolution := parser.opts.Duplicates
+	firstLine := parser.keyLines[parser.currentSection][key]
+
+	switch resolution {
+	case DuplicatePolicyError:
+		fatalErr := parser.fatal(1, ErrKeyAlreadyExists).(*ParseError)
+		fatalErr.Key = key
+		return false, fatalErr
+	case DuplicatePolicyLastWins, DuplicatePolicyMerge:
+		resolution = DuplicatePolicyLastWins
+		parser.currentKV.Keys[key] = sT // Replace the earlier value, keeping its place in the section's Order
+		parser.supersedeLayout(parser.currentSection, key)
+		superseded = true
+	case DuplicatePolicyFirstWins:
+	default: // First wins, warning about it since no policy was asked for
+		parser.warn(1, parser.currentSection, key, ErrKeyAlreadyExists)
+		resolution = DuplicatePolicyFirstWins
+	}
+
+	parser.decide(parser.currentSection, key, firstLine, resolution)
+	return
+}
+
+// decide will record how a duplicate section or key on the current line was resolved
+func (parser *schemaParser) decide(section string, key string, firstLine int, resolution string) {
+	parser.schema.Duplicates = append(parser.schema.Duplicates, DuplicateDecision{
+		FirstLine:  firstLine,
+		Key:        key,
+		Line:       parser.line,
+		Resolution: resolution,
+		Section:    section,
+	})
+}
+
+// parseKey will parse a key=val line into our current section
+// As with GKeyFile, whitespace around the = is not part of the key or value
+func (parser *schemaParser) parseKey(line string) error {
+	if parser.skipping { // Already warned about the section
+		parser.addLayoutLine(layoutLine{kind: layoutOther, raw: line})
+		return nil
+	}
+
+	equals := strings.Index(line, "=")
+
+	if equals == -1 { // Not a key=val
+		parser.warn(1, parser.currentSection, "", ErrParseNotKeyValue)
+		parser.addLayoutLine(layoutLine{kind: layoutOther, raw: line})
+		return nil
+	}
+
+	key := strings.TrimSpace(line[:equals])
+	valueStart := len(line) - len(strings.TrimLeft(line[equals+1:], " \t")) // Index the value starts at
+
+	if parser.currentKV == nil { // Not in a section
+		parser.warn(1, "", key, ErrParseKeyOutsideSection)
+		parser.addLayoutLine(layoutLine{kind: layoutOther, raw: line})
+		return nil
+	}
+
+	if validateErr := ValidateKeyName(key); validateErr != nil { // dconf would reject this key
+		parser.warn(1, parser.currentSection, key, validateErr)
+		parser.addLayoutLine(layoutLine{kind: layoutOther, raw: line})
+		return nil
+	}
+
+	if parser.opts.MaxValueSize > 0 && len(line)-valueStart > parser.opts.MaxValueSize { // Value is too large
+		return parser.fatal(valueStart+1, ErrValueTooLarge)
+	}
+
+	sT, parseErr := NewSchemaType(line[valueStart:]) // Attempt to parse our "raw" value to a SchemaType
+
+	if parseErr != nil { // Not a value we could parse
+		parser.warn(valueStart+1, parser.currentSection, key, fmt.Errorf("%w: %s", ErrParseInvalidValue, parseErr))
+		parser.addLayoutLine(layoutLine{kind: layoutOther, raw: line})
+		return nil
+	}
+
+	if parser.currentKV.AddKey(key, sT) != nil { // Already have this key
+		superseded, duplicateErr := parser.duplicateKey(key, sT)
+
+		if !superseded { // Earlier key wins, or we failed
+			parser.addLayoutLine(layoutLine{kind: layoutOther, raw: line})
+			return duplicateErr
+		}
+	} else {
+		parser.keyLines[parser.currentSection][key] = parser.line
+	}
+
+	parser.addLayoutLine(layoutLine{
+		kind:    layoutKey,
+		key:     key,
+		keyText: line[:valueStart],
+		raw:     line,
+		section: parser.currentSection,
+		value:   sT.Duplicate(),
+	})
+
+	return nil
+}
+
+// warn will record a problem with the current line
+func (parser *schemaParser) warn(column int, section string, key string, err error) {
+	parser.schema.Warnings = append(parser.schema.Warnings, &ParseError{
+		Column:   column,
+		Err:      err,
+		FileName: parser.opts.FileName,
+		Key:      key,
+		Line:     parser.line,
+		Section:  section,
+	})
+}
+
+// fatal will return a ParseError for a problem with the current line we cannot continue parsing after
+func (parser *schemaParser) fatal(column int, err error) error {
+	return &ParseError{
+		Column:   column,
+		Err:      err,
+		FileName: parser.opts.FileName,
+		Line:     parser.line,
+		Section:  parser.currentSection,
+	}
+}
+
+// finish will return our parsed Schema, or our problems as ParseErrors if we are parsing strictly
+func (parser *schemaParser) finish() (schema *Schema, parseErr error) {
+	if parser.opts.Strict && len(parser.schema.Warnings) != 0 {
+		parseErr = parser.schema.Warnings
+		return
+	}
+
+	schema = parser.schema
+	return
+}
+
+// Error will return the ParseError as file:line:column: [section] key: problem
+func (parseErr *ParseError) Error() string {
+	position := fmt.Sprintf("%d:%d", parseErr.Line, parseErr.Column)
+
+	if parseErr.FileName != "" {
+		position = parseErr.FileName + ":" + position
+	}
+
+	if parseErr.Section != "" {
+		position += " [" + parseErr.Section + "]"
+	}
+
+	if parseErr.Key != "" {
+		position += " " + parseErr.Key
+	}
+
+	return position + ": " + parseErr.Err.Error()
+}
+
+// Unwrap will return the underlying error, so errors.Is works with our sentinel errors
+func (parseErr *ParseError) Unwrap() error {
+	return parseErr.Err
+}
+
+// Error will return all our ParseErrors, one per line
+func (parseErrs ParseErrors) Error() string {
+	messages := []string{}
+
+	for _, parseErr := range parseErrs {
+		messages = append(messages, parseErr.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// addLayoutLine will record the line in our layout, if we are parsing losslessly
+func (parser *schemaParser) addLayoutLine(line layoutLine) {
+	if parser.schema.layout != nil {
+		parser.schema.layout.lines = append(parser.schema.layout.lines, line)
+	}
+}
+
+// supersedeLayout will keep the layout lines of a replaced key, or every key of a replaced section if key is empty, as-is
+// Only the lines of the replacement are then rewritten should it change
+func (parser *schemaParser) supersedeLayout(section string, key string) {
+	if parser.schema.layout == nil {
+		return
+	}
+
+	for index, line := range parser.schema.layout.lines {
+		if line.kind == layoutKey && line.section == section && (key == "" || line.key == key) {
+			parser.schema.layout.lines[index].kind = layoutOther
+		}
+	}
+}
+
+// trailingNewline will record that our content ended with a newline
+func (parser *schemaParser) trailingNewline() {
+	if parser.schema.layout != nil {
+		parser.schema.layout.trailingNewline = true
+	}
+}
@@ -0,0 +1,226 @@
+/* parser_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+var BrokenKeyfile = []byte(`orphan=true
+[applets/clock]
+name='Clock'
+size=uint32 abc
+not a key
+name='Clock again'
+
+[applets/clock]
+position=7
+`)
+
+// TestParseLenient will test that lenient parsing skips problems and reports them as warnings
+func TestParseLenient(t *testing.T) {
+	schema, parseErr := NewSchemaWithOptions("/", BrokenKeyfile, ParseOptions{FileName: "broken"})
+
+	if parseErr != nil {
+		t.Fatalf("Lenient parsing failed: %s", parseErr)
+	}
+
+	if len(schema.Warnings) != 5 {
+		t.Fatalf("Expected 5 warnings, got %d instead:\n%s", len(schema.Warnings), schema.Warnings)
+	}
+
+	expected := []error{ErrParseKeyOutsideSection, ErrParseInvalidValue, ErrParseNotKeyValue, ErrKeyAlreadyExists, ErrSectionExists}
+
+	for index, warning := range schema.Warnings {
+		if !errors.Is(warning, expected[index]) {
+			t.Errorf("Expected warning %d to be %v, got %v instead.", index, expected[index], warning)
+		}
+	}
+
+	if warning := schema.Warnings[1]; warning.Error() != "broken:4:6 [applets/clock] size: invalid value: strconv.ParseUint: parsing \"abc\": invalid syntax" {
+		t.Errorf("Unexpected warning message: %s", warning)
+	}
+
+	kv, _ := schema.GetSection("applets/clock")
+
+	if !kv.HasKey("position") || kv.HasKey("size") { // Good keys kept, bad ones skipped
+		t.Errorf("Unexpected keys after lenient parsing: %v", kv.Keys)
+	}
+}
+
+// TestParseStrict will test that strict parsing fails with ParseErrors
+func TestParseStrict(t *testing.T) {
+	schema, parseErr := NewSchemaWithOptions("/", BrokenKeyfile, ParseOptions{Strict: true})
+
+	var parseErrs ParseErrors
+	if !errors.As(parseErr, &parseErrs) || len(parseErrs) != 5 {
+		t.Fatalf("Expected 5 ParseErrors, got %v instead.", parseErr)
+	}
+
+	if schema != nil {
+		t.Error("Strict parsing returned a Schema despite errors")
+	}
+
+	if _, parseErr = NewSchemaWithOptions("/", ExampleContent, ParseOptions{Strict: true}); parseErr != nil {
+		t.Errorf("Strict parsing of our example failed: %s", parseErr)
+	}
+}
+
+// TestNewSchemaFromReader will test NewSchemaFromReader, including our limits
+func TestNewSchemaFromReader(t *testing.T) {
+	file, _ := os.Open("examples/com__solus-project__budgie-panel")
+	defer file.Close()
+
+	schema, parseErr := NewSchemaFromReader("/com/solus-project/budgie-panel/", file, ParseOptions{Strict: true})
+
+	if parseErr != nil {
+		t.Fatalf("Failed to parse from reader: %s", parseErr)
+	}
+
+	if schema.String() != NewTestSchema(t).String() { // Should be the same as parsing all at once
+		t.Error("Parsing from a reader produced a different Schema than NewSchema")
+	}
+
+	limits := []struct {
+		opts     ParseOptions
+		expected error
+	}{
+		{ParseOptions{MaxLineLength: 20}, ErrLineTooLong},
+		{ParseOptions{MaxSections: 3}, ErrTooManySections},
+		{ParseOptions{MaxValueSize: 10}, ErrValueTooLarge},
+	}
+
+	for _, limit := range limits {
+		if _, parseErr = NewSchemaFromReader("/", bytes.NewReader(ExampleContent), limit.opts); !errors.Is(parseErr, limit.expected) {
+			t.Errorf("Expected %v, got %v instead.", limit.expected, parseErr)
+		}
+	}
+
+	if _, parseErr = NewSchemaFromReader("/", strings.NewReader(""), ParseOptions{}); parseErr != ErrNoContentProvided {
+		t.Errorf("Expected ErrNoContentProvided, got %v instead.", parseErr)
+	}
+}
+
+// TestWriteTo will test WriteTo
+func TestWriteTo(t *testing.T) {
+	schema := NewTestSchema(t)
+
+	var buffer bytes.Buffer
+	written, writeErr := schema.WriteTo(&buffer)
+
+	if writeErr != nil {
+		t.Fatalf("Failed to write: %s", writeErr)
+	}
+
+	if written != int64(buffer.Len()) || buffer.String() != schema.String() {
+		t.Errorf("WriteTo wrote %d bytes which do not match String", written)
+	}
+}
+
+var DuplicateKeyfile = []byte(`[applets/clock]
+name='Clock'
+size=uint32 1
+
+[applets/menu]
+name='Menu'
+
+[applets/clock]
+name='Clock again'
+position=7
+`)
+
+// TestParseDuplicates will test each of our DuplicatePolicy constants
+func TestParseDuplicates(t *testing.T) {
+	tests := []struct {
+		policy     string
+		name       string
+		keys       []string
+		resolution []string
+	}{
+		{"", "'Clock'", []string{"name", "position", "size"}, []string{DuplicatePolicyMerge, DuplicatePolicyFirstWins}},
+		{DuplicatePolicyFirstWins, "'Clock'", []string{"name", "size"}, []string{DuplicatePolicyFirstWins}},
+		{DuplicatePolicyLastWins, "'Clock again'", []string{"name", "position"}, []string{DuplicatePolicyLastWins}},
+		{DuplicatePolicyMerge, "'Clock again'", []string{"name", "position", "size"}, []string{DuplicatePolicyMerge, DuplicatePolicyLastWins}},
+	}
+
+	for _, test := range tests {
+		schema, parseErr := NewSchemaWithOptions("/", DuplicateKeyfile, ParseOptions{Duplicates: test.policy})
+
+		if parseErr != nil {
+			t.Fatalf("Failed to parse with policy %q: %s", test.policy, parseErr)
+		}
+
+		if strings.Join(schema.Order, ",") != "applets/clock,applets/menu" {
+			t.Errorf("Unexpected order with policy %q: %v", test.policy, schema.Order)
+		}
+
+		kv := schema.Map["applets/clock"]
+
+		if strings.Join(kv.Order, ",") != strings.Join(test.keys, ",") || kv.Keys["name"].Val != test.name {
+			t.Errorf("Unexpected keys with policy %q: %v %v", test.policy, kv.Order, kv.Keys)
+		}
+
+		if len(schema.Duplicates) != len(test.resolution) {
+			t.Fatalf("Expected %d decisions with policy %q, got %v instead.", len(test.resolution), test.policy, schema.Duplicates)
+		}
+
+		for index, decision := range schema.Duplicates {
+			if decision.Resolution != test.resolution[index] {
+				t.Errorf("Expected decision %d with policy %q to be %s, got %v instead.", index, test.policy, test.resolution[index], decision)
+			}
+		}
+
+		if decision := schema.Duplicates[0]; decision.Section != "applets/clock" || decision.FirstLine != 1 || decision.Line != 8 {
+			t.Errorf("Unexpected decision with policy %q: %+v", test.policy, decision)
+		}
+
+		if test.policy == "" && len(schema.Warnings) != 2 || test.policy != "" && len(schema.Warnings) != 0 {
+			t.Errorf("Unexpected warnings with policy %q: %v", test.policy, schema.Warnings)
+		}
+	}
+
+	_, parseErr := NewSchemaWithOptions("/", DuplicateKeyfile, ParseOptions{Duplicates: DuplicatePolicyError})
+
+	var posErr *ParseError
+	if !errors.As(parseErr, &posErr) || !errors.Is(parseErr, ErrSectionExists) || posErr.Line != 8 {
+		t.Errorf("Expected ErrSectionExists on line 8, got %v instead.", parseErr)
+	}
+
+	if _, parseErr = NewSchemaWithOptions("/", DuplicateKeyfile, ParseOptions{Duplicates: "last-win"}); !errors.Is(parseErr, ErrInvalidDuplicatePolicy) {
+		t.Errorf("Expected ErrInvalidDuplicatePolicy for a misspelled policy, got %v instead.", parseErr)
+	}
+}
+
+// TestParseDuplicatesLossless will test that a replaced key is kept as-is when writing a lossless Schema back out
+func TestParseDuplicatesLossless(t *testing.T) {
+	schema, _ := NewSchemaWithOptions("/", DuplicateKeyfile, ParseOptions{Duplicates: DuplicatePolicyMerge, Lossless: true})
+
+	if schema.String() != string(DuplicateKeyfile) {
+		t.Errorf("Unmodified Schema changed:\n%s", schema.String())
+	}
+
+	schema.Map["applets/clock"].Keys["name"], _ = NewSchemaType("'Renamed'")
+
+	if expected := strings.Replace(string(DuplicateKeyfile), "'Clock again'", "'Renamed'", 1); schema.String() != expected {
+		t.Errorf("Expected only the last name to be rewritten, got:\n%s", schema.String())
+	}
+}
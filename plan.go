@@ -0,0 +1,144 @@
+/* plan.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// PlanActionAdd means the key does not exist in dconf and will be added
+	PlanActionAdd = "add"
+
+	// PlanActionOverwrite means the key exists in dconf with a different value and will be overwritten
+	PlanActionOverwrite = "overwrite"
+
+	// PlanActionUnchanged means the key exists in dconf with the same value
+	PlanActionUnchanged = "unchanged"
+)
+
+// Plan will dump the live dconf state under our Path and plan what importing our Schema into it would change
+// Nothing is imported, use ApplyPlan to import once the plan has been reviewed
+func (schema *Schema) Plan() (plan *ImportPlan, planErr error) {
+	var live []byte
+	if live, planErr = DconfDump(schema.Path); planErr != nil { // Failed to dump the live state
+		return
+	}
+
+	return schema.PlanAgainst(live)
+}
+
+// PlanAgainst will plan what importing our Schema would change, against the provided dconf dump of our Path
+// This is useful for previewing against a dump taken from another machine
+func (schema *Schema) PlanAgainst(live []byte) (plan *ImportPlan, planErr error) {
+	liveSchema, parseErr := NewSchema(schema.Path, live)
+
+	if parseErr == ErrNoContentProvided { // Nothing under our path yet
+		liveSchema = &Schema{Map: make(map[string]*SchemaKV)}
+	} else if parseErr != nil {
+		planErr = parseErr
+		return
+	}
+
+	plan = &ImportPlan{
+		Path:    schema.Path,
+		Changes: []PlanChange{},
+		live:    append([]byte{}, live...),
+		schema:  schema.String(),
+	}
+
+	for _, section := range schema.sortedSections() {
+		kv := schema.Map[section]
+		liveKv := liveSchema.Map[section]
+
+		for _, key := range kv.sortedKeys() {
+			change := PlanChange{
+				Action:  PlanActionAdd,
+				Key:     key,
+				New:     kv.Keys[key].String(),
+				Section: section,
+			}
+
+			if liveKv != nil && liveKv.HasKey(key) { // Already exists in dconf
+				liveSt := liveKv.Keys[key]
+				change.Old = liveSt.String()
+
+				if liveSt.Matches(kv.Keys[key]) {
+					change.Action = PlanActionUnchanged
+				} else {
+					change.Action = PlanActionOverwrite
+				}
+			}
+
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+
+	return
+}
+
+// ApplyPlan will import our Schema into dconf, provided neither the live state nor our Schema changed since planning
+func (schema *Schema) ApplyPlan(plan *ImportPlan) (applyErr error) {
+	if plan.Path != schema.Path || plan.schema != schema.String() { // Plan isn't for this Schema as it is now
+		return ErrPlanStale
+	}
+
+	var live []byte
+	if live, applyErr = DconfDump(schema.Path); applyErr != nil { // Failed to dump the live state
+		return
+	}
+
+	if !bytes.Equal(live, plan.live) { // Live state changed since planning
+		return ErrPlanStale
+	}
+
+	return schema.ImportIntoDconfAtPath()
+}
+
+// Count will return how many keys in the plan have the provided PlanAction
+func (plan *ImportPlan) Count(action string) (count int) {
+	for _, change := range plan.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+
+	return
+}
+
+// String will render the plan for humans
+// Keys being added are prefixed with +, keys being overwritten with ~. Unchanged keys are only counted
+func (plan *ImportPlan) String() string {
+	lines := []string{
+		fmt.Sprintf("Import into %s: %d to add, %d to overwrite, %d unchanged",
+			plan.Path, plan.Count(PlanActionAdd), plan.Count(PlanActionOverwrite), plan.Count(PlanActionUnchanged)),
+	}
+
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case PlanActionAdd:
+			lines = append(lines, fmt.Sprintf("+ [%s] %s=%s", change.Section, change.Key, change.New))
+		case PlanActionOverwrite:
+			lines = append(lines, fmt.Sprintf("~ [%s] %s: %s -> %s", change.Section, change.Key, change.Old, change.New))
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,64 @@
+/* plan_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestPlanAgainst will test PlanAgainst
+func TestPlanAgainst(t *testing.T) {
+	schema := NewTestSchema(t)
+	live := []byte("[/]\ndark-theme=false\nlayout='solus-fortitude'\n\n[applets/{8bc94562-0dae-11eb-ad1d-e0d55e200f1c}]\nname='Clock'\n")
+
+	plan, planErr := schema.PlanAgainst(live)
+
+	if planErr != nil {
+		t.Fatalf("Failed to plan: %s", planErr)
+	}
+
+	if plan.Count(PlanActionOverwrite) != 1 || plan.Count(PlanActionUnchanged) != 2 {
+		t.Errorf("Expected 1 overwrite and 2 unchanged, got %d and %d instead.", plan.Count(PlanActionOverwrite), plan.Count(PlanActionUnchanged))
+	}
+
+	if plan.Count(PlanActionAdd) != len(plan.Changes)-3 {
+		t.Errorf("Expected everything else to be added, got %d adds of %d changes.", plan.Count(PlanActionAdd), len(plan.Changes))
+	}
+
+	if rendered := plan.String(); !strings.Contains(rendered, "~ [/] dark-theme: false -> true") {
+		t.Errorf("Rendered plan does not show the dark-theme overwrite:\n%s", rendered)
+	}
+
+	if emptyPlan, _ := schema.PlanAgainst(nil); emptyPlan.Count(PlanActionAdd) != len(emptyPlan.Changes) { // Nothing live, everything is added
+		t.Error("Expected every key to be added when nothing is live")
+	}
+}
+
+// TestApplyPlanStale will test that ApplyPlan rejects a plan once the Schema has changed
+func TestApplyPlanStale(t *testing.T) {
+	schema := NewTestSchema(t)
+	plan, _ := schema.PlanAgainst(nil)
+
+	schema.DeleteSectionsWithPrefix("applets")
+
+	if applyErr := schema.ApplyPlan(plan); !errors.Is(applyErr, ErrPlanStale) {
+		t.Errorf("Expected ErrPlanStale, got %v instead.", applyErr)
+	}
+}
@@ -0,0 +1,72 @@
+/* prune.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// PruneDefaults will return a copy of the Schema without any keys set to their default, along with a report of what was removed
+// Keys left at their default stop a change of default from taking effect, and make diffs between dumps noisy
+// Sections are mapped to GSettings schemas with a SchemaMapper, so relocatable schemas are pruned by their mounts
+// overrides, if not nil, holds the defaults of vendor overrides as from LoadGSchemaOverrides, and takes precedence over the
+// defaults of the source. Compiled sources already have them applied
+func PruneDefaults(schema *Schema, source SchemaSource, overrides *Schema) (pruned *Schema, report *PruneReport) {
+	pruned = schema.Clone()
+	report = &PruneReport{
+		Pruned:   []PrunedKey{},
+		Sections: []string{},
+	}
+
+	mapper := NewSchemaMapper(source)
+
+	for _, section := range schema.sortedSections() {
+		id := mapper.SchemaID(schema.KeyPath(section, ""))
+		gschema := source.Schema(id)
+
+		if gschema == nil { // Nothing to compare against
+			continue
+		}
+
+		kv := pruned.Map[section]
+
+		for _, key := range kv.sortedKeys() {
+			gkey := gschema.Keys[key]
+
+			if gkey == nil {
+				continue
+			}
+
+			defaultText := gkey.Default
+
+			if overrides != nil && overrides.Map[id] != nil && overrides.Map[id].HasKey(key) {
+				defaultText = overrides.Map[id].Keys[key].rawValue()
+			}
+
+			if !gvariantTextEqual(kv.Keys[key].rawValue(), defaultText) { // As written, since String may change how GVariant reads it
+				continue
+			}
+
+			kv.DeleteKeys(key)
+			report.Pruned = append(report.Pruned, PrunedKey{Default: defaultText, Key: key, Schema: id, Section: section})
+		}
+
+		if len(kv.Keys) == 0 {
+			pruned.DeleteSections(section)
+			report.Sections = append(report.Sections, section)
+		}
+	}
+
+	return
+}
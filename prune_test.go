@@ -0,0 +1,92 @@
+/* prune_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"testing"
+)
+
+// TestPruneDefaults will test pruning our example content, with and without a vendor override
+func TestPruneDefaults(t *testing.T) {
+	set := NewBudgieGSchemaSet(t)
+	schema := NewTestSchema(t)
+	fingerprint := schema.Fingerprint()
+
+	pruned, report := PruneDefaults(schema, set, nil)
+
+	if schema.Fingerprint() != fingerprint {
+		t.Error("Expected the original Schema to be unchanged")
+	}
+
+	root := pruned.Map["/"]
+
+	if root == nil || root.HasKey("dark-theme") || !root.HasKey("layout") || !root.HasKey("migration-level") {
+		t.Errorf("Unexpected root section: %+v", root)
+	}
+
+	if applet := pruned.Map["applets/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}"]; applet == nil || len(applet.Keys) != 1 || !applet.HasKey("name") {
+		t.Errorf("Expected only the name of the menu applet to remain, got %+v", applet)
+	}
+
+	if panel := pruned.Map["panels/{e41d503c-103d-11eb-b26a-e0d55e200f1c}"]; panel == nil || len(panel.Keys) != 2 || !panel.HasKey("size") {
+		t.Errorf("Expected the applets and size of the second panel to remain, got %+v", panel)
+	}
+
+	if len(report.Sections) != 1 || report.Sections[0] != "instance/icon-tasklist/{e420825c-103d-11eb-b26a-e0d55e200f1c}" || pruned.HasSection(report.Sections[0]) {
+		t.Errorf("Expected the second icon tasklist to be pruned entirely, got %v", report.Sections)
+	}
+
+	if first := report.Pruned[0]; first.Key != "dark-theme" || first.Section != "/" || first.Schema != "com.solus-project.budgie-panel" || first.Default != "true" {
+		t.Errorf("Unexpected first pruned key: %+v", first)
+	}
+
+	for _, prunedKey := range report.Pruned {
+		if pruned.Map[prunedKey.Section] != nil && pruned.Map[prunedKey.Section].HasKey(prunedKey.Key) {
+			t.Errorf("Expected %s in %s to be pruned", prunedKey.Key, prunedKey.Section)
+		}
+	}
+
+	overrides, _ := NewSchema("", []byte("[com.solus-project.budgie-panel.panel]\nsize=39\n\n[com.solus-project.budgie-panel]\ndark-theme=false\n"))
+	pruned, _ = PruneDefaults(schema, set, overrides)
+
+	if !pruned.Map["/"].HasKey("dark-theme") {
+		t.Error("Expected dark-theme to be kept, as it is overridden to false")
+	}
+
+	if pruned.Map["panels/{e41d503c-103d-11eb-b26a-e0d55e200f1c}"].HasKey("size") || pruned.Map["panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}"].HasKey("size") {
+		t.Error("Expected panel sizes to be pruned, as they match the override")
+	}
+}
+
+// TestPruneDefaultsAsWritten will test that values are compared to defaults as written, as formatting may lose precision
+func TestPruneDefaultsAsWritten(t *testing.T) {
+	set, _ := ParseGSchemaXML([]byte(`<schemalist>
+  <schema id="org.example.numbers" path="/org/example/numbers/">
+    <key name="count" type="i"><default>1000000</default></key>
+    <key name="offset" type="x"><default>9007199254740993</default></key>
+    <key name="total" type="x"><default>9007199254740993</default></key>
+  </schema>
+</schemalist>`))
+
+	schema, _ := NewSchema("/org/example/numbers/", []byte("[/]\ncount=1000000\noffset=9007199254740993\ntotal=9007199254740992\n"))
+	pruned, report := PruneDefaults(schema, set, nil)
+
+	if root := pruned.Map["/"]; root == nil || root.HasKey("count") || root.HasKey("offset") || !root.HasKey("total") {
+		t.Errorf("Expected count and offset to be pruned and not total, pruned %+v", report.Pruned)
+	}
+}
@@ -0,0 +1,597 @@
+/* query.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// Queries are expressions like: section ~ "applets/*" and name == 'Clock'
+//
+// Comparisons are field op literal, combined with and, or, not and parentheses. Fields are:
+//   section  the path of the section, like applets/clock (empty for the root section)
+//   key      the name of the key
+//   path     the path of the key, like applets/clock/name
+//   value(name) compares the value of the key with that name, for keys named like the fields above
+//   anything else is the name of a key in the section, comparing its value
+// Operators are == != < <= > >= ~ (glob, or regex when the literal starts with re:), !~ and contains (array item or substring)
+// Literals are single or double quoted strings, numbers (digits with an optional sign, point and exponent), and true or false
+//
+// Values are compared by type: numbers numerically, strings after decoding from the GVariant text format
+// Comparisons against a key the section does not have, or a value of another type, never match (except for !=)
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	queryTokenEnd = iota
+	queryTokenIdent
+	queryTokenNumber
+	queryTokenOp
+	queryTokenParen
+	queryTokenString
+)
+
+// queryNode is a node of a parsed Query
+type queryNode interface {
+	eval(ctx *queryContext) bool
+}
+
+// queryContext is what a Query is evaluated against, a section or a single key of it
+type queryContext struct {
+	key     string // Key, empty when evaluating a section
+	kv      *SchemaKV
+	section string
+}
+
+// queryAnd matches when both sides do
+type queryAnd struct {
+	left, right queryNode
+}
+
+// queryOr matches when either side does
+type queryOr struct {
+	left, right queryNode
+}
+
+// queryNot matches when its node does not
+type queryNot struct {
+	node queryNode
+}
+
+// queryComparison compares a field against a literal
+type queryComparison struct {
+	field   string
+	isValue bool // Whether the field is the name of a key, as with value(name)
+	literal queryLiteral
+	op      string
+	pattern *regexp.Regexp // For ~ and !~
+}
+
+// queryLiteral is a literal value in a Query
+type queryLiteral struct {
+	kind   string // bool, number or string
+	bool   bool
+	number float64
+	str    string
+}
+
+// queryToken is a token of a Query
+type queryToken struct {
+	kind   int
+	column int
+	text   string // Text of the token, decoded for strings
+}
+
+// queryParser parses a Query from its tokens
+type queryParser struct {
+	position int
+	tokens   []queryToken
+}
+
+// NewQuery will parse the Query, returning an ErrQuerySyntax error with the column of any problem
+func NewQuery(source string) (query *Query, queryErr error) {
+	tokens, tokenErr := tokenizeQuery(source)
+
+	if tokenErr != nil {
+		queryErr = tokenErr
+		return
+	}
+
+	parser := &queryParser{tokens: tokens}
+	root, parseErr := parser.parseOr()
+
+	if parseErr != nil {
+		queryErr = parseErr
+		return
+	}
+
+	if token := parser.peek(); token.kind != queryTokenEnd { // Something left over
+		queryErr = token.errorf("unexpected %s", token)
+		return
+	}
+
+	query = &Query{
+		Source: source,
+		root:   root,
+	}
+
+	return
+}
+
+// FindKeys will return every key the Query matches, sorted by section and then key
+// Fields naming other keys compare against the keys of the same section
+func (schema *Schema) FindKeys(query *Query) (found []SelectedKey) {
+	found = []SelectedKey{}
+
+	for _, section := range schema.sortedSections() {
+		kv := schema.Map[section]
+
+		for _, key := range kv.sortedKeys() {
+			if query.root.eval(&queryContext{key: key, kv: kv, section: section}) {
+				found = append(found, SelectedKey{Key: key, Section: section})
+			}
+		}
+	}
+
+	return
+}
+
+// FindSections will return every section the Query matches, sorted
+// Comparisons of the key and path fields match if any key of the section does
+func (schema *Schema) FindSections(query *Query) (found []string) {
+	found = []string{}
+
+	for _, section := range schema.sortedSections() {
+		if query.root.eval(&queryContext{kv: schema.Map[section], section: section}) {
+			found = append(found, section)
+		}
+	}
+
+	return
+}
+
+func (node *queryAnd) eval(ctx *queryContext) bool {
+	return node.left.eval(ctx) && node.right.eval(ctx)
+}
+
+func (node *queryOr) eval(ctx *queryContext) bool {
+	return node.left.eval(ctx) || node.right.eval(ctx)
+}
+
+func (node *queryNot) eval(ctx *queryContext) bool {
+	return !node.node.eval(ctx)
+}
+
+func (node *queryComparison) eval(ctx *queryContext) bool {
+	switch {
+	case node.isValue:
+		return node.compareKey(ctx)
+	case node.field == "section":
+		return node.compareString(selectorSectionPath(ctx.section))
+	case node.field == "key" || node.field == "path":
+		keys := []string{ctx.key}
+
+		if ctx.key == "" { // Evaluating a section, match if any key does
+			keys = ctx.kv.sortedKeys()
+		}
+
+		for _, key := range keys {
+			name := key
+
+			if node.field == "path" {
+				name = selectorKeyPath(ctx.section, key)
+			}
+
+			if node.compareString(name) {
+				return true
+			}
+		}
+
+		return false
+	default:
+		return node.compareKey(ctx)
+	}
+}
+
+// compareKey will compare the value of the key named by our field against our literal
+func (node *queryComparison) compareKey(ctx *queryContext) bool {
+	sT, exists := ctx.kv.Keys[node.field]
+
+	if !exists { // Nothing to compare
+		return false
+	}
+
+	return node.compareValue(sT)
+}
+
+// compareValue will compare the SchemaType against our literal, by type
+func (node *queryComparison) compareValue(sT *SchemaType) bool {
+	if node.op == "contains" {
+		return node.contains(sT)
+	}
+
+	switch sT.Type {
+	case "bool":
+		if node.literal.kind == "bool" {
+			return node.compareOrdered(boolToFloat(sT.BoolVal), boolToFloat(node.literal.bool), true)
+		}
+	case "uint32", "int32", "float64":
+		if node.literal.kind == "number" {
+			return node.compareOrdered(numericValue(sT), node.literal.number, false)
+		}
+	default:
+		if str, isString := decodedString(sT.Val); isString && node.literal.kind == "string" {
+			return node.compareString(str)
+		}
+	}
+
+	return node.op == "!=" // Different types are never equal
+}
+
+// compareString will compare the string against our literal
+func (node *queryComparison) compareString(str string) bool {
+	if node.pattern != nil {
+		return node.pattern.MatchString(str) == (node.op == "~")
+	}
+
+	if node.literal.kind != "string" {
+		return node.op == "!="
+	}
+
+	switch node.op {
+	case "==":
+		return str == node.literal.str
+	case "!=":
+		return str != node.literal.str
+	case "<":
+		return str < node.literal.str
+	case "<=":
+		return str <= node.literal.str
+	case ">":
+		return str > node.literal.str
+	case ">=":
+		return str >= node.literal.str
+	default: // contains
+		return strings.Contains(str, node.literal.str)
+	}
+}
+
+// compareOrdered will compare the numbers with our operator, only allowing equality if unordered
+func (node *queryComparison) compareOrdered(value float64, literal float64, unordered bool) bool {
+	switch node.op {
+	case "==":
+		return value == literal
+	case "!=":
+		return value != literal
+	}
+
+	if unordered { // Bools have no order
+		return false
+	}
+
+	switch node.op {
+	case "<":
+		return value < literal
+	case "<=":
+		return value <= literal
+	case ">":
+		return value > literal
+	case ">=":
+		return value >= literal
+	}
+
+	return false
+}
+
+// contains will check if the SchemaType, as an array, has an item equal to our literal, or as a string, contains it
+func (node *queryComparison) contains(sT *SchemaType) bool {
+	items, splitErr := SplitArray(sT.Val)
+
+	if splitErr != nil { // Not an array
+		str, isString := decodedString(sT.Val)
+		return isString && sT.Type == "string" && node.literal.kind == "string" && strings.Contains(str, node.literal.str)
+	}
+
+	equals := &queryComparison{field: node.field, isValue: node.isValue, literal: node.literal, op: "=="}
+
+	for _, item := range items {
+		if itemSt, parseErr := NewSchemaType(item); parseErr == nil && equals.compareValue(itemSt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseOr will parse: and ("or" and)*
+func (parser *queryParser) parseOr() (node queryNode, parseErr error) {
+	if node, parseErr = parser.parseAnd(); parseErr != nil {
+		return
+	}
+
+	for parser.peek().isKeyword("or") {
+		parser.position++
+
+		var right queryNode
+		if right, parseErr = parser.parseAnd(); parseErr != nil {
+			return
+		}
+
+		node = &queryOr{left: node, right: right}
+	}
+
+	return
+}
+
+// parseAnd will parse: not ("and" not)*
+func (parser *queryParser) parseAnd() (node queryNode, parseErr error) {
+	if node, parseErr = parser.parseNot(); parseErr != nil {
+		return
+	}
+
+	for parser.peek().isKeyword("and") {
+		parser.position++
+
+		var right queryNode
+		if right, parseErr = parser.parseNot(); parseErr != nil {
+			return
+		}
+
+		node = &queryAnd{left: node, right: right}
+	}
+
+	return
+}
+
+// parseNot will parse: "not" not | "(" or ")" | comparison
+func (parser *queryParser) parseNot() (node queryNode, parseErr error) {
+	token := parser.next()
+
+	switch {
+	case token.isKeyword("not"):
+		if node, parseErr = parser.parseNot(); parseErr == nil {
+			node = &queryNot{node: node}
+		}
+	case token.kind == queryTokenParen && token.text == "(":
+		if node, parseErr = parser.parseOr(); parseErr != nil {
+			return
+		}
+
+		if closing := parser.next(); closing.kind != queryTokenParen || closing.text != ")" {
+			parseErr = closing.errorf("expected ) but got %s", closing)
+		}
+	case token.isKeyword("value") && parser.peek().kind == queryTokenParen && parser.peek().text == "(":
+		parser.position++
+		name := parser.next()
+
+		if name.kind != queryTokenIdent && name.kind != queryTokenNumber && name.kind != queryTokenString {
+			parseErr = name.errorf("expected a key name but got %s", name)
+			return
+		}
+
+		if closing := parser.next(); closing.kind != queryTokenParen || closing.text != ")" {
+			parseErr = closing.errorf("expected ) but got %s", closing)
+			return
+		}
+
+		node, parseErr = parser.parseComparison(name, true)
+	case token.kind == queryTokenIdent:
+		node, parseErr = parser.parseComparison(token, false)
+	default:
+		parseErr = token.errorf("expected a field, not or ( but got %s", token)
+	}
+
+	return
+}
+
+// parseComparison will parse the operator and literal following the field, which names a key if isValue
+func (parser *queryParser) parseComparison(field queryToken, isValue bool) (node queryNode, parseErr error) {
+	op := parser.next()
+
+	if op.kind != queryTokenOp && !op.isKeyword("contains") {
+		parseErr = op.errorf("expected a comparison after %s but got %s", field.text, op)
+		return
+	}
+
+	value := parser.next()
+	comparison := &queryComparison{field: field.text, isValue: isValue, op: op.text}
+
+	switch {
+	case value.kind == queryTokenString:
+		comparison.literal = queryLiteral{kind: "string", str: value.text}
+	case value.kind == queryTokenNumber:
+		number, _ := strconv.ParseFloat(value.text, 64) // Already validated when tokenizing
+		comparison.literal = queryLiteral{kind: "number", number: number}
+	case value.isKeyword("true"), value.isKeyword("false"):
+		comparison.literal = queryLiteral{kind: "bool", bool: value.text == "true"}
+	default:
+		parseErr = value.errorf("expected a string, number, true or false but got %s", value)
+		return
+	}
+
+	if op.text == "~" || op.text == "!~" {
+		if comparison.literal.kind != "string" {
+			parseErr = value.errorf("%s needs a string pattern", op.text)
+			return
+		}
+
+		if comparison.pattern, parseErr = queryPattern(comparison, value.text); parseErr != nil {
+			parseErr = value.errorf("%s", parseErr)
+			return
+		}
+	}
+
+	return comparison, nil
+}
+
+// queryPattern will compile the pattern of a ~ comparison
+// Patterns for the section, key and path fields are globs as with Selector, for values * and ? match any characters
+func queryPattern(comparison *queryComparison, pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "re:") { // Is intended to be regex
+		return regexp.Compile(strings.TrimPrefix(pattern, "re:"))
+	}
+
+	if field := comparison.field; !comparison.isValue && (field == "section" || field == "key" || field == "path") {
+		selector, selectErr := NewSelector(pattern)
+
+		if selectErr != nil {
+			return nil, selectErr
+		}
+
+		return selector.regexp, nil
+	}
+
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.ReplaceAll(expression, `\*`, ".*")
+	expression = strings.ReplaceAll(expression, `\?`, ".")
+	return regexp.Compile("^" + expression + "$")
+}
+
+// tokenizeQuery will split the Query into tokens
+func tokenizeQuery(source string) (tokens []queryToken, tokenErr error) {
+	tokens = []queryToken{}
+
+	for index := 0; index < len(source); {
+		char := source[index]
+		start := index
+
+		switch {
+		case char == ' ' || char == '\t' || char == '\n':
+			index++
+			continue
+		case char == '(' || char == ')':
+			tokens = append(tokens, queryToken{kind: queryTokenParen, column: start + 1, text: string(char)})
+			index++
+		case char == '\'' || char == '"':
+			end := index + 1
+
+			for end < len(source) && source[end] != char {
+				if source[end] == '\\' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(source) {
+				return nil, queryToken{column: start + 1}.errorf("unterminated string")
+			}
+
+			str, unquoteErr := UnquoteString(source[start : end+1])
+
+			if unquoteErr != nil {
+				return nil, queryToken{column: start + 1}.errorf("%s", unquoteErr)
+			}
+
+			tokens = append(tokens, queryToken{kind: queryTokenString, column: start + 1, text: str})
+			index = end + 1
+		case strings.ContainsRune("=!<>~", rune(char)):
+			op := string(char)
+
+			if index+1 < len(source) && (source[index+1] == '=' || (char == '!' && source[index+1] == '~')) {
+				op += string(source[index+1])
+			}
+
+			if op == "=" || op == "!" { // Not an operator on its own
+				return nil, queryToken{column: start + 1}.errorf("unexpected %q", op)
+			}
+
+			tokens = append(tokens, queryToken{kind: queryTokenOp, column: start + 1, text: op})
+			index += len(op)
+		default:
+			for index < len(source) && !strings.ContainsRune(" \t\n()'\"=!<>~", rune(source[index])) {
+				index++
+			}
+
+			word := source[start:index]
+			kind := queryTokenIdent
+
+			if _, parseErr := strconv.ParseFloat(word, 64); parseErr == nil && strings.Trim(word, "0123456789+-.eE") == "" { // Not inf, nan or hex
+				kind = queryTokenNumber
+			}
+
+			tokens = append(tokens, queryToken{kind: kind, column: start + 1, text: word})
+		}
+	}
+
+	tokens = append(tokens, queryToken{kind: queryTokenEnd, column: len(source) + 1})
+	return
+}
+
+// peek will return the next token without consuming it
+func (parser *queryParser) peek() queryToken {
+	return parser.tokens[parser.position]
+}
+
+// next will consume and return the next token, the end token being returned repeatedly
+func (parser *queryParser) next() queryToken {
+	token := parser.tokens[parser.position]
+
+	if token.kind != queryTokenEnd {
+		parser.position++
+	}
+
+	return token
+}
+
+// isKeyword will check if the token is the provided keyword
+func (token queryToken) isKeyword(keyword string) bool {
+	return token.kind == queryTokenIdent && token.text == keyword
+}
+
+// String will describe the token for errors
+func (token queryToken) String() string {
+	if token.kind == queryTokenEnd {
+		return "end of query"
+	}
+
+	return strconv.Quote(token.text)
+}
+
+// errorf will return an ErrQuerySyntax error for the token's column
+func (token queryToken) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: column %d: %s", ErrQuerySyntax, token.column, fmt.Sprintf(format, args...))
+}
+
+// decodedString will decode the value as a string, if it is a quoted string
+func decodedString(val string) (string, bool) {
+	str, unquoteErr := UnquoteString(val)
+	return str, unquoteErr == nil
+}
+
+// numericValue will return the numeric value of the SchemaType as a float64
+func numericValue(sT *SchemaType) float64 {
+	switch sT.Type {
+	case "uint32":
+		return float64(sT.UintVal)
+	case "int32":
+		return float64(sT.IntVal)
+	default:
+		return sT.FloatVal
+	}
+}
+
+// boolToFloat will convert the bool to 1 or 0, so bools can be compared like numbers
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
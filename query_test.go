@@ -0,0 +1,123 @@
+/* query_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestFindSections will test finding sections with queries against our example
+func TestFindSections(t *testing.T) {
+	schema := NewTestSchema(t)
+
+	tests := map[string]string{
+		`section ~ "applets/*" and name == 'Clock'`:                                        "applets/{8bc94562-0dae-11eb-ad1d-e0d55e200f1c},applets/{e42c3eda-103d-11eb-b26a-e0d55e200f1c}",
+		`section ~ "panels/*" and applets contains '8bc94562-0dae-11eb-ad1d-e0d55e200f1c'`: "panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}",
+		`position > 6 and not (alignment != "end")`:                                        "applets/{8bc94562-0dae-11eb-ad1d-e0d55e200f1c},applets/{8bc9a034-0dae-11eb-ad1d-e0d55e200f1c}",
+		`dark-theme == true`:                  "/",
+		`dark-theme == 'true'`:                "",
+		`name ~ "Clo*" or name ~ "re:^Night"`: "applets/{8bc94562-0dae-11eb-ad1d-e0d55e200f1c},applets/{da699038-2f45-11eb-b2c4-e0d55e200f1c},applets/{e42c3eda-103d-11eb-b26a-e0d55e200f1c}",
+	}
+
+	for source, expected := range tests {
+		query, queryErr := NewQuery(source)
+
+		if queryErr != nil {
+			t.Fatalf("Failed to parse %s: %s", source, queryErr)
+		}
+
+		if found := schema.FindSections(query); strings.Join(found, ",") != expected {
+			t.Errorf("Expected %s to find %s, got %v instead.", source, expected, found)
+		}
+	}
+
+	query, _ := NewQuery(`section ~ "panels/*" and applets contains 'e42c3eda-103d-11eb-b26a-e0d55e200f1c'`)
+
+	if found := schema.FindSections(query); strings.Join(found, ",") != "panels/{e41d503c-103d-11eb-b26a-e0d55e200f1c}" {
+		t.Errorf("Expected the second panel to hold the second clock, got %v instead.", found)
+	}
+
+	query, _ = NewQuery(`section ~ "applets/*" and position >= 7`)
+
+	if found := schema.FindSections(query); len(found) != 2 {
+		t.Errorf("Expected 2 applets with a position of at least 7, got %v instead.", found)
+	}
+}
+
+// TestFindKeys will test finding keys with queries
+func TestFindKeys(t *testing.T) {
+	schema := NewTestSchema(t)
+	query, _ := NewQuery(`key == "name" and name ~ "*Indicator"`)
+
+	if found := schema.FindKeys(query); len(found) != 5 || found[0].Key != "name" {
+		t.Errorf("Expected 5 indicator names, got %v instead.", found)
+	}
+}
+
+// TestFindValueFields will test comparing the values of keys named like fields, with value(name)
+func TestFindValueFields(t *testing.T) {
+	schema, _ := NewSchema("/org/example/", []byte("[a]\nsection='x'\nkey=1\npath='p/*'\n\n[b]\nsection='y'\n"))
+
+	tests := map[string]string{
+		`value(section) == 'x'`:         "a",
+		`value("section") != 'x'`:       "b",
+		`value(key) >= 1`:               "a",
+		`value(path) ~ 'p/*'`:           "a",
+		`section == 'x'`:                "",
+		`key == 'section' and key == 1`: "",
+	}
+
+	for source, expected := range tests {
+		query, queryErr := NewQuery(source)
+
+		if queryErr != nil {
+			t.Fatalf("Failed to parse %s: %s", source, queryErr)
+		}
+
+		if found := schema.FindSections(query); strings.Join(found, ",") != expected {
+			t.Errorf("Expected %s to find %s, got %v instead.", source, expected, found)
+		}
+	}
+}
+
+// TestNewQueryErrors will test that invalid queries fail with a column
+func TestNewQueryErrors(t *testing.T) {
+	tests := map[string]string{
+		`name ==`:                  "column 8: expected a string, number, true or false but got end of query",
+		`name = 'Clock'`:           "column 6: unexpected \"=\"",
+		`(name == 'Clock'`:         "column 17: expected ) but got end of query",
+		`name == 'Clock' position`: "column 17: unexpected \"position\"",
+		`name 'Clock'`:             "column 6: expected a comparison after name but got \"Clock\"",
+		`name == 'Clock`:           "column 9: unterminated string",
+		`position ~ 3`:             "column 12: ~ needs a string pattern",
+		`position < inf`:           "column 12: expected a string, number, true or false but got \"inf\"",
+		`position == nan`:          "column 13: expected a string, number, true or false but got \"nan\"",
+		`value(==) == 1`:           "column 7: expected a key name but got \"==\"",
+		`value(name == 1`:          "column 12: expected ) but got \"==\"",
+	}
+
+	for source, expected := range tests {
+		_, queryErr := NewQuery(source)
+
+		if !errors.Is(queryErr, ErrQuerySyntax) || !strings.HasSuffix(queryErr.Error(), expected) {
+			t.Errorf("Expected %s to fail with %s, got %v instead.", source, expected, queryErr)
+		}
+	}
+}
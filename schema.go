@@ -19,7 +19,10 @@ package libdconf
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os/exec"
 	"regexp"
 	"sort"
@@ -28,6 +31,8 @@ import (
 
 var (
 	// SectionRegexp is our regular expression for a section name
+	//
+	// Deprecated: This only permits a subset of valid section names. Use ValidateSectionName instead
 	SectionRegexp = regexp.MustCompile(`^\[([A-Za-z0-9-_\:\.\/{}]+)\]`)
 )
 
@@ -36,63 +41,11 @@ var (
 // This will be done user running the command.
 // If we fail to dump or parse the schema, we will return an error
 func NewSchema(path string, content []byte) (schema *Schema, readErr error) {
-	if content == nil || (len(content) == 0) { // content not specified or has no content
-		readErr = ErrNoContentProvided
-		return
-	}
-
-	schema = &Schema{
-		Map:   make(map[string]*SchemaKV),
-		Order: []string{},
-		Path:  path,
-	}
-
-	cs := string(content[:]) // Convert our byte array to a string
-
-	lines := strings.Split(cs, "\n") // Split on new line
-
-	var currentSection string // Our current section, sub-folder, whatever you want to call it
-	var currentKV *SchemaKV   // Key value store
-
-	for _, line := range lines {
-		if line == "" { // If this is an empty new line
-			if currentSection != "" && currentKV != nil { // If we have a current section
-				schema.Map[currentSection] = currentKV
-			}
-
-			currentSection = "" // Reset to an empty section name
-			currentKV = nil     // Force as nil for future check
-			continue
-		}
-
-		if currentKV == nil { // No valid map
-			currentKV = &SchemaKV{
-				Order: []string{},
-				Keys:  make(map[string]*SchemaType),
-			}
-		}
-
-		sectionMatches := SectionRegexp.FindAllString(line, 1) // Find all our matches, limit to 1 though
-
-		if len(sectionMatches) == 1 { // If we have a match
-			currentSection = sectionMatches[0]
-			currentSection = strings.TrimPrefix(currentSection, "[")
-			currentSection = strings.TrimSuffix(currentSection, "]")
-
-			schema.Order = append(schema.Order, currentSection) // Add our section
-		} else { // If we didn't find a section per our regex
-			key, sT := ParseSchemaLine(line) // Attempt to parse our schema type
-
-			if sT.Type != "" { // If we got something. omg yay
-				currentKV.AddKey(key, sT) // Add the key
-			}
-		}
-	}
-
-	return
+	return NewSchemaWithOptions(path, content, ParseOptions{})
 }
 
 // ParseSchemaLine will parse our key=val line in an attempt to figure out its type
+// As with GKeyFile, whitespace around the = is not part of the key or value
 func ParseSchemaLine(line string) (key string, t *SchemaType) {
 	keyValArr := strings.SplitN(line, "=", 2) // Split between key and value
 
@@ -100,8 +53,8 @@ func ParseSchemaLine(line string) (key string, t *SchemaType) {
 		return
 	}
 
-	key = keyValArr[0]     // Set key to first position in array
-	rawVal := keyValArr[1] // Set our raw value
+	key = strings.TrimSpace(keyValArr[0])           // Set key to first position in array
+	rawVal := strings.TrimLeft(keyValArr[1], " \t") // Set our raw value
 
 	parsedSt, parseErr := NewSchemaType(rawVal) // Attempt to parse our "raw" value to a SchemaType
 
@@ -113,8 +66,12 @@ func ParseSchemaLine(line string) (key string, t *SchemaType) {
 }
 
 // AddSection will attempt to add the provided SchemaKV as the provided section name
-// This will return an error if the section already exists
+// This will return an error if the section already exists or the section name is not valid, see ValidateSectionName
 func (schema *Schema) AddSection(section string, sT *SchemaKV) (addErr error) {
+	if addErr = ValidateSectionName(section); addErr != nil { // dconf would reject this section
+		return
+	}
+
 	if schema.HasSection(section) { // Section already exists
 		addErr = ErrSectionExists
 		return
@@ -122,31 +79,100 @@ func (schema *Schema) AddSection(section string, sT *SchemaKV) (addErr error) {
 
 	schema.Map[section] = sT
 	schema.Order = append(schema.Order, section) // Append to the existing order
+	schema.tree = nil                            // Rebuild our index with the new section
 	return
 }
 
+// Clone will create a deep copy of our Schema, changing which never affects the original
+// Warnings and Duplicates from parsing, and any SchemaIDs, are copied too
+func (schema *Schema) Clone() *Schema {
+	newSchema := &Schema{
+		Map:    make(map[string]*SchemaKV),
+		Order:  append([]string{}, schema.Order...),
+		Path:   schema.Path,
+		layout: schema.layout, // Our layout is never modified after parsing, so it can be shared
+	}
+
+	for section, kv := range schema.Map { // For each section
+		newSchema.Map[section] = kv.Duplicate()
+	}
+
+	if schema.Duplicates != nil {
+		newSchema.Duplicates = append([]DuplicateDecision{}, schema.Duplicates...)
+	}
+
+	if schema.SchemaIDs != nil {
+		newSchema.SchemaIDs = make(map[string]string)
+
+		for section, id := range schema.SchemaIDs {
+			newSchema.SchemaIDs[section] = id
+		}
+	}
+
+	for _, warning := range schema.Warnings {
+		newWarning := *warning
+		newSchema.Warnings = append(newSchema.Warnings, &newWarning)
+	}
+
+	return newSchema
+}
+
 // DeleteSections will delete all sections specified should they match exactly
 // If you want to match by prefix, use the DeleteSectionsWithPrefix func
 func (schema *Schema) DeleteSections(sections ...string) {
 	for _, section := range sections { // For each section
-		section = TrimSectionSlashes(section)
+		if section != "/" { // Don't trim the root section down to nothing
+			section = TrimSectionSlashes(section)
+		}
+
 		delete(schema.Map, section)                               // Delete the section
+		delete(schema.SchemaIDs, section)                         // Along with its schema ID, if any
 		schema.Order = RemoveFromStringArr(schema.Order, section) // Remove the section from the string array
 	}
+
+	schema.tree = nil // Rebuild our index without the sections
 }
 
-// DeleteSectionsWithPrefix will delete all sections specified and all sections which begin with the section prefixed
+// DeleteSectionsWithPrefix will delete all sections specified and all sections under them
+// Prefixes respect directory boundaries, so applets deletes applets/clock but not applets-old
 func (schema *Schema) DeleteSectionsWithPrefix(sections ...string) {
-	for sectionInMap := range schema.Map { // For each section in the map
-		for _, section := range sections { // For each of the sections
-			section = TrimSectionSlashes(section)
+	schema.DeleteSections(schema.sectionsWithPrefix(sections...)...)
+}
 
-			if strings.HasPrefix(sectionInMap, section) { // If this section in the map begins with the section specified
-				schema.DeleteSections(sectionInMap)
-				break // Break immediately since we don't need to check the other sections
-			}
+// Equal will check if both Schemas have the same Path and sections, with SchemaKVs that are Equal
+// The order of sections and keys is not considered, nor is anything recorded while parsing
+func (schema *Schema) Equal(oSchema *Schema) bool {
+	if schema.Path != oSchema.Path || len(schema.Map) != len(oSchema.Map) {
+		return false
+	}
+
+	for section, kv := range schema.Map {
+		oKv, exists := oSchema.Map[section]
+
+		if !exists || !kv.Equal(oKv) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Fingerprint will return a stable hash of the content of our Schema, as hex
+// Schemas which are Equal have the same Fingerprint, regardless of their order or how their values were written
+func (schema *Schema) Fingerprint() string {
+	hash := sha256.New()
+	hash.Write([]byte(schema.Path + "\x00"))
+
+	for _, section := range schema.sortedSections() {
+		kv := schema.Map[section]
+		hash.Write([]byte("[" + section + "]\x00"))
+
+		for _, key := range kv.sortedKeys() {
+			hash.Write([]byte(key + "\x00" + kv.Keys[key].canonicalValue() + "\x00"))
 		}
 	}
+
+	return hex.EncodeToString(hash.Sum(nil))
 }
 
 // GetSection will attempt to get the SchemaKV associated with the provided section
@@ -167,7 +193,19 @@ func (schema *Schema) HasSection(section string) (exists bool) {
 }
 
 // ImportIntoDconf will import this Schema into its path via dconf load
+// Our sections are loaded relative to /, so use ImportIntoDconfAtPath for a Schema with sections relative to its Path
 func (schema *Schema) ImportIntoDconf() (importErr error) {
+	return schema.dconfLoad("/")
+}
+
+// ImportIntoDconfAtPath will import this Schema via dconf load of our Path, defaulting to /
+// This is the counterpart of DconfDump, whose sections are relative to the path dumped
+func (schema *Schema) ImportIntoDconfAtPath() (importErr error) {
+	return schema.dconfLoad(schema.basePath())
+}
+
+// dconfLoad will load our Schema into dconf, with our sections relative to the provided path
+func (schema *Schema) dconfLoad(path string) (importErr error) {
 	schemaContent := []byte(schema.String())
 
 	if len(schemaContent) == 0 { // No content
@@ -177,79 +215,159 @@ func (schema *Schema) ImportIntoDconf() (importErr error) {
 
 	bytesReader := bytes.NewReader(schemaContent) // Create a bytes reader for the file contents
 
-	var dconfPath string
-	dconfPath, importErr = exec.LookPath("dconf") // Get the path to the dconf binary
-
-	if importErr != nil { // Failed to look up the path
+	if _, importErr = exec.LookPath("dconf"); importErr != nil { // Failed to look up dconf
 		importErr = ErrNoDconfInPath
+		return
 	}
 
-	dconfLoad := exec.Cmd{
-		Path: dconfPath,
-		Args: []string{
-			"load",
-			"/",
-		},
-		Stdin: bytesReader,
-	}
+	dconfLoad := exec.Command("dconf", "load", path)
+	dconfLoad.Stdin = bytesReader
 
-	if importErr = dconfLoad.Start(); importErr != nil { // Failed to run the command
+	if importErr = dconfLoad.Run(); importErr != nil { // Failed to run the command
 		importErr = fmt.Errorf("failed during execution of dconf load: %s", importErr)
-		return
 	}
 
-	dconfLoad.Wait()
 	return
 }
 
 // MigrateSectionsWithName will migrate sections with the source prefix specified, remapping them to have the destination prefix.
 // If exact is set to true, we will only migrate the section if it is an exact match
-func (schema *Schema) MigrateSectionsWithName(source string, dest string, exact bool) {
+// If any new section name is invalid or already exists, nothing is migrated and the error is returned
+func (schema *Schema) MigrateSectionsWithName(source string, dest string, exact bool) (migrateErr error) {
+	migrations := schema.sectionsToMigrate(source, dest, exact)
+	moving := make(map[string]bool)
+
+	for _, migration := range migrations {
+		moving[migration[0]] = true
+	}
+
+	for _, migration := range migrations { // Check every new section before changing anything
+		newSection := migration[1]
+
+		if migrateErr = ValidateSectionName(newSection); migrateErr != nil { // dconf would reject this section
+			return
+		}
+
+		if schema.HasSection(newSection) && !moving[newSection] { // Would overwrite a section we are not migrating
+			migrateErr = fmt.Errorf("%w: %s", ErrSectionExists, newSection)
+			return
+		}
+	}
+
+	kvs := make([]*SchemaKV, 0, len(migrations))
+
+	for _, migration := range migrations { // Delete all the old sections first, in case a new section is named after one
+		kvs = append(kvs, schema.Map[migration[0]].Duplicate())
+		schema.DeleteSections(migration[0])
+	}
+
+	for index, migration := range migrations {
+		schema.AddSection(migration[1], kvs[index]) // Can't fail, we checked the name and it no longer exists
+	}
+
+	return
+}
+
+// sectionsToMigrate will return the pairs of existing and new section names MigrateSectionsWithName would migrate
+func (schema *Schema) sectionsToMigrate(source string, dest string, exact bool) (pairs [][2]string) {
 	source = TrimSectionSlashes(source)
 	dest = TrimSectionSlashes(dest)
 
-	for sectionKey, sectionKV := range schema.Map { // For each section in the map
-		if !strings.HasPrefix(sectionKey, source) || // Doesn't have source in the name of the section
-			(exact && sectionKey != source) { // exact specified and section key isn't the same as source
-			continue // Skip it
+	sections := schema.sectionsWithPrefix(source) // Respect directory boundaries, so applets doesn't match applets-old
+
+	if exact { // Only the section that is the same as source
+		sections = nil
+
+		if schema.HasSection(source) {
+			sections = []string{source}
 		}
+	}
 
+	for _, sectionKey := range sections { // For each section we need to migrate
 		newSection := strings.TrimPrefix(sectionKey, source) // Remove the source
 		newSection = dest + newSection                       // Prepend the destination
 
-		schema.AddSection(newSection, sectionKV.Duplicate()) // Add the new section
-		schema.DeleteSections(sectionKey)                    // Delete the old section
+		pairs = append(pairs, [2]string{sectionKey, newSection})
 	}
+
+	return
 }
 
 // String will convert our Schema back to a String
-func (schema *Schema) String() (schemaString string) {
-	lines := []string{}        // Set our lines that we'll use to ensure newlines and the like
-	sort.Strings(schema.Order) // Sort our order
+// If the Schema was parsed losslessly, its original layout is retained. Otherwise sections and keys are sorted alphabetically
+func (schema *Schema) String() string {
+	var builder strings.Builder
+	schema.WriteTo(&builder) // Writing to a strings.Builder never fails
+	return builder.String()
+}
+
+// WriteTo will write our Schema to the provided writer, in the same format as String
+// This writes a line at a time rather than building the entire Schema as a string first
+func (schema *Schema) WriteTo(w io.Writer) (written int64, writeErr error) {
+	writeLine := func(line string) bool { // Write the line and our newline, returning if we succeeded
+		n, lineErr := io.WriteString(w, line+"\n")
+		written += int64(n)
+		writeErr = lineErr
+		return lineErr == nil
+	}
+
+	if schema.layout != nil { // Parsed losslessly
+		lines := schema.losslessLines()
+
+		for index, line := range lines {
+			if index == len(lines)-1 && !schema.layout.trailingNewline { // Content did not end with a newline
+				n, lineErr := io.WriteString(w, line)
+				written += int64(n)
+				writeErr = lineErr
+				return
+			}
+
+			if !writeLine(line) {
+				return
+			}
+		}
 
-	for _, section := range schema.Order { // Use order so our sections are organized alphabetically
+		return
+	}
+
+	wroteSection := false
+
+	for _, section := range schema.sortedSections() { // Use sorted sections so our sections are organized alphabetically
 		kv := schema.Map[section] // Get our key/value
 
 		if kv == nil || len(kv.Keys) == 0 { // No keys
 			continue
 		}
 
-		sectionLabel := fmt.Sprintf("[%s]", section) // Ensure we re-add [ and ]
-
-		lines = append(lines, sectionLabel) // Add our section
+		if wroteSection && !writeLine("") { // Separate from the previous section with an empty line
+			return
+		}
 
-		sort.Strings(kv.Order) // Order our SchemaKV
+		if !writeLine(fmt.Sprintf("[%s]", section)) { // Ensure we re-add [ and ]
+			return
+		}
 
-		for _, orderedKey := range kv.Order { // For each of our ordered keys
-			sT := kv.Keys[orderedKey]
-			lines = append(lines, orderedKey+"="+sT.String()) // Add in alphabetical order
+		for _, orderedKey := range kv.sortedKeys() { // For each of our keys, in alphabetical order
+			if !writeLine(orderedKey + "=" + kv.Keys[orderedKey].String()) {
+				return
+			}
 		}
 
-		lines = append(lines, "", "") // Add explicit new line after all our key/values
+		wroteSection = true
 	}
 
-	schemaString = strings.Join(lines, "\n")                      // Join our strings, separated by newline
-	schemaString = strings.ReplaceAll(schemaString, "\n\n", "\n") // Replace double newlines with just one
-
 	return
 }
+
+// sortedSections will return the names of all our sections, sorted
+// Unlike String, this does not sort our Order in place
+func (schema *Schema) sortedSections() []string {
+	sections := make([]string, 0, len(schema.Map))
+
+	for section := range schema.Map {
+		sections = append(sections, section)
+	}
+
+	sort.Strings(sections)
+	return sections
+}
@@ -24,8 +24,12 @@ import (
 )
 
 // AddKey will attempt to add the SchemaType for the provided key to the SchemaKV
-// This will return an error if the key already exists
+// This will return an error if the key already exists or the key name is not valid, see ValidateKeyName
 func (kv *SchemaKV) AddKey(key string, t *SchemaType) error {
+	if validateErr := ValidateKeyName(key); validateErr != nil { // dconf would reject this key
+		return validateErr
+	}
+
 	if kv.HasKey(key) { // Already exists
 		return ErrKeyAlreadyExists
 	}
@@ -48,7 +52,7 @@ func (kv *SchemaKV) DeleteKeys(keys ...string) {
 // Duplicate will duplicate this SchemaKV into a new SchemaKV
 func (kv *SchemaKV) Duplicate() *SchemaKV {
 	newKv := SchemaKV{
-		Order: []string{},
+		Order: append([]string{}, kv.Order...), // Make a copy of the old Kv order for the new one
 		Keys:  make(map[string]*SchemaType),
 	}
 
@@ -56,10 +60,27 @@ func (kv *SchemaKV) Duplicate() *SchemaKV {
 		newKv.Keys[kvKey] = kvVal.Duplicate() // Duplicate the SchemaType and assign it
 	}
 
-	copy(newKv.Order, kv.Order) // Make a copy of the old Kv order to the new one
 	return &newKv
 }
 
+// Equal will check if both SchemaKVs have the same keys, with values that match per SchemaType's Matches
+// The order of keys is not considered
+func (kv *SchemaKV) Equal(oKv *SchemaKV) bool {
+	if len(kv.Keys) != len(oKv.Keys) {
+		return false
+	}
+
+	for key, sT := range kv.Keys {
+		oSt, exists := oKv.Keys[key]
+
+		if !exists || !sT.Matches(oSt) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // GetVal will get the SchemaType value for the provided key, or return an error
 func (kv *SchemaKV) GetVal(key string) (*SchemaType, error) {
 	val, exists := kv.Keys[key]
@@ -77,6 +98,18 @@ func (kv *SchemaKV) HasKey(key string) bool {
 	return exists
 }
 
+// sortedKeys will return the names of all our keys, sorted
+func (kv *SchemaKV) sortedKeys() []string {
+	keys := make([]string, 0, len(kv.Keys))
+
+	for key := range kv.Keys {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
 // ModifyKey will attempt to modify a SchemaType specified by key, with the provided Modification
 func (kv *SchemaKV) ModifyKey(key string, mod Modification) (modErr error) {
 	if !kv.HasKey(key) { // If we don't have this key
@@ -149,8 +182,31 @@ func (kv *SchemaKV) MoveKey(source string, dest string) error {
 		return ErrKeyAlreadyExists
 	}
 
-	kv.AddKey(dest, sT)   // Add the new section
-	kv.DeleteKeys(source) // Delete the source key
+	if addErr := kv.AddKey(dest, sT); addErr != nil { // Failed to add the new key, like dest being an invalid name
+		return addErr
+	}
 
+	kv.DeleteKeys(source) // Delete the source key
 	return nil
 }
+
+// orderKeys will order our keys to match the provided keys, with any of ours not provided after them in their current order
+func (kv *SchemaKV) orderKeys(keys []string) {
+	order := make([]string, 0, len(kv.Order))
+	ordered := make(map[string]bool)
+
+	for _, key := range keys {
+		if kv.HasKey(key) && !ordered[key] {
+			order = append(order, key)
+			ordered[key] = true
+		}
+	}
+
+	for _, key := range kv.Order {
+		if !ordered[key] {
+			order = append(order, key)
+		}
+	}
+
+	kv.Order = order
+}
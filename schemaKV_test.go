@@ -18,6 +18,7 @@
 package libdconf
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -49,6 +50,37 @@ func TestSchemaKVDuplicate(t *testing.T) {
 	if !pinnedKVDuplicate.HasKey("only-pinned") { // Failed to duplicate properlty
 		t.Errorf("Failed to properly duplicate KV, missing only-pinned.\n%v", pinnedKVDuplicate)
 	}
+
+	if strings.Join(pinnedKVDuplicate.Order, ",") != strings.Join(TestSchemaKV.Order, ",") { // Order should be retained
+		t.Errorf("Duplicate lost the key order, expected %v, got %v instead.", TestSchemaKV.Order, pinnedKVDuplicate.Order)
+	}
+
+	pinnedKVDuplicate.Keys["only-pinned"].BoolVal = !pinnedKVDuplicate.Keys["only-pinned"].BoolVal
+
+	if TestSchemaKV.Keys["only-pinned"].BoolVal == pinnedKVDuplicate.Keys["only-pinned"].BoolVal {
+		t.Error("Changing the duplicate changed the original")
+	}
+}
+
+// TestSchemaKVEqual will test SchemaKV's Equal
+func TestSchemaKVEqual(t *testing.T) {
+	kv := TestSchemaKV.Duplicate()
+
+	if !kv.Equal(TestSchemaKV) {
+		t.Error("Duplicate is not Equal to the original")
+	}
+
+	kv.Keys["only-pinned"], _ = NewSchemaType("uint32 1")
+
+	if kv.Equal(TestSchemaKV) {
+		t.Error("SchemaKVs with different values are Equal")
+	}
+
+	kv.DeleteKeys("only-pinned")
+
+	if kv.Equal(TestSchemaKV) {
+		t.Error("SchemaKVs with different keys are Equal")
+	}
 }
 
 // TestGetVal will test GetVal
@@ -101,3 +133,23 @@ func TestMoveKey(t *testing.T) {
 		t.Errorf("Failed to get super-pinned-launchers value: %s", moveErr)
 	}
 }
+
+// TestMoveKeyInvalidName will test that moving a key to an invalid name fails without deleting the key
+func TestMoveKeyInvalidName(t *testing.T) {
+	schema, _ := NewSchema("/org/example/", []byte("[/]\nk=true\n"))
+	kv, _ := schema.GetSection("/")
+
+	if moveErr := kv.MoveKey("k", "bad/name"); !errors.Is(moveErr, ErrInvalidKeyName) {
+		t.Errorf("Expected ErrInvalidKeyName, got %v instead.", moveErr)
+	}
+
+	if !kv.HasKey("k") || kv.HasKey("bad/name") || len(kv.Order) != 1 {
+		t.Errorf("Expected only k to remain, got %v", kv.Order)
+	}
+
+	selector, _ := NewSelector("k")
+
+	if _, moveErr := schema.MoveKeysMatching(selector, "bad/name"); !errors.Is(moveErr, ErrInvalidKeyName) || !kv.HasKey("k") {
+		t.Errorf("Expected ErrInvalidKeyName with k left in place, got %v instead.", moveErr)
+	}
+}
@@ -0,0 +1,208 @@
+/* schemaMapper.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// schemaMapperMount is a GSchemaMount with its glob compiled
+type schemaMapperMount struct {
+	expression *regexp.Regexp
+	mount      GSchemaMount
+}
+
+// NewSchemaMapper will create a SchemaMapper for the GSettings schemas of the source
+// A path maps to the schema with that path, a relocatable schema mounted at it, or a child of the schema at its parent
+func NewSchemaMapper(source SchemaSource) *SchemaMapper {
+	mapper := &SchemaMapper{
+		fixed:    make(map[string]string),
+		resolved: make(map[string]SchemaMapping),
+		source:   source,
+	}
+
+	for _, id := range source.IDs() {
+		if schema := source.Schema(id); schema != nil && !schema.Relocatable() {
+			mapper.fixed[schema.Path] = id
+		}
+	}
+
+	for _, mount := range source.Mounts() {
+		if expression, compileErr := regexp.Compile(globToRegexp(mount.Path)); compileErr == nil { // GSchemaSet checks globs, other sources may not
+			mapper.mounts = append(mapper.mounts, schemaMapperMount{expression: expression, mount: mount})
+		}
+	}
+
+	return mapper
+}
+
+// Lookup will return the mapping of the dconf path to its schema, and whether there is one
+// Paths may be full dconf paths, like /org/gnome/desktop/interface/, or sections of a Schema at /, like org/gnome/desktop/interface
+func (mapper *SchemaMapper) Lookup(path string) (mapping SchemaMapping, found bool) {
+	mapping = mapper.resolve(normalizeSchemaPath(path))
+	return mapping, mapping.ID != ""
+}
+
+// SchemaID will return the ID of the schema at the dconf path, empty if there is none. Paths are as with Lookup
+func (mapper *SchemaMapper) SchemaID(path string) string {
+	mapping, _ := mapper.Lookup(path)
+	return mapping.ID
+}
+
+// LookupSection will return the mapping of the section of the Schema to its schema, and whether there is one
+func (mapper *SchemaMapper) LookupSection(schema *Schema, section string) (SchemaMapping, bool) {
+	return mapper.Lookup(schema.KeyPath(section, ""))
+}
+
+// Paths will return everywhere the schema is: its own path, its paths as a child of other schemas, and the mounts it is at
+// Mounts have no Path, as the schema may be at any number of paths matching them
+func (mapper *SchemaMapper) Paths(id string) []SchemaMapping {
+	if mapper.paths == nil { // Build our reverse index
+		mapper.paths = make(map[string][]SchemaMapping)
+
+		for _, path := range mapper.sortedFixedPaths() {
+			mapper.indexPaths(SchemaMapping{ID: mapper.fixed[path], Path: path}, make(map[string]bool))
+		}
+
+		for _, mount := range mapper.mounts {
+			mapper.indexPaths(SchemaMapping{ID: mount.mount.Schema, Mount: mount.mount.Path}, make(map[string]bool))
+		}
+	}
+
+	return append([]SchemaMapping{}, mapper.paths[id]...)
+}
+
+// indexPaths will add the mapping and those of the schema's children to our reverse index
+func (mapper *SchemaMapper) indexPaths(mapping SchemaMapping, visiting map[string]bool) {
+	schema := mapper.source.Schema(mapping.ID)
+
+	if schema == nil || visiting[mapping.ID] { // Unknown schema, or a child of itself
+		return
+	}
+
+	mapper.paths[mapping.ID] = append(mapper.paths[mapping.ID], mapping)
+	visiting[mapping.ID] = true
+
+	for _, child := range schema.Children {
+		childMapping := SchemaMapping{ID: child.Schema}
+
+		if mapping.Path != "" {
+			childMapping.Path = mapping.Path + child.Name + "/"
+		} else {
+			childMapping.Mount = mapping.Mount + child.Name + "/"
+		}
+
+		mapper.indexPaths(childMapping, visiting)
+	}
+
+	delete(visiting, mapping.ID)
+}
+
+// sortedFixedPaths will return the paths of our schemas with a fixed path, sorted
+func (mapper *SchemaMapper) sortedFixedPaths() []string {
+	paths := make([]string, 0, len(mapper.fixed))
+
+	for path := range mapper.fixed {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+	return paths
+}
+
+// resolve will return the mapping of the dconf directory, like /com/solus-project/budgie-panel/applets/{UUID}/
+// The mapping has an empty ID if there is no schema at the directory
+func (mapper *SchemaMapper) resolve(dir string) (mapping SchemaMapping) {
+	if mapping, exists := mapper.resolved[dir]; exists {
+		return mapping
+	}
+
+	defer func() {
+		mapper.resolved[dir] = mapping
+	}()
+
+	mapping.Path = dir
+
+	if id, exists := mapper.fixed[dir]; exists {
+		mapping.ID = id
+		return
+	}
+
+	for _, mount := range mapper.mounts {
+		if mount.expression.MatchString(dir) {
+			mapping.ID, mapping.Mount = mount.mount.Schema, mount.mount.Path
+			return
+		}
+	}
+
+	trimmed := strings.TrimSuffix(dir, "/")
+	slash := strings.LastIndexByte(trimmed, '/')
+
+	if slash == -1 { // Root
+		return
+	}
+
+	parentMapping := mapper.resolve(trimmed[:slash+1])
+	parent := mapper.source.Schema(parentMapping.ID)
+
+	if parent == nil {
+		return
+	}
+
+	for _, child := range parent.Children {
+		if child.Name == trimmed[slash+1:] {
+			mapping.ID = child.Schema
+
+			if parentMapping.Mount != "" { // Child of a mounted schema, so mounted with it
+				mapping.Mount = parentMapping.Mount + child.Name + "/"
+			}
+
+			return
+		}
+	}
+
+	return
+}
+
+// owner will return the ID of the schema at the nearest parent of the dconf directory, empty if there is none
+func (mapper *SchemaMapper) owner(dir string) string {
+	for dir != "/" {
+		dir = dir[:strings.LastIndexByte(strings.TrimSuffix(dir, "/"), '/')+1]
+
+		if id := mapper.resolve(dir).ID; mapper.source.Schema(id) != nil {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// normalizeSchemaPath will return the path as a full dconf directory path, with a leading and trailing /
+func normalizeSchemaPath(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	return path
+}
@@ -0,0 +1,94 @@
+/* schemaMapper_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSchemaMapperLookup will test mapping paths and sections to schemas
+func TestSchemaMapperLookup(t *testing.T) {
+	mapper := NewSchemaMapper(NewBudgieGSchemaSet(t))
+
+	tests := map[string]SchemaMapping{
+		"/com/solus-project/budgie-panel/":                         {ID: "com.solus-project.budgie-panel", Path: "/com/solus-project/budgie-panel/"},
+		"com/solus-project/budgie-panel":                           {ID: "com.solus-project.budgie-panel", Path: "/com/solus-project/budgie-panel/"},
+		"com/solus-project/budgie-panel/applets/{abc}":             {ID: "com.solus-project.budgie-panel.applet", Mount: "/com/solus-project/budgie-panel/applets/*/", Path: "/com/solus-project/budgie-panel/applets/{abc}/"},
+		"/com/solus-project/budgie-panel/instance/tray/{abc}/":     {ID: "com.solus-project.tray", Mount: "/com/solus-project/budgie-panel/instance/tray/*/", Path: "/com/solus-project/budgie-panel/instance/tray/{abc}/"},
+		"/com/solus-project/budgie-panel/instance/old-applet/{a}/": {Path: "/com/solus-project/budgie-panel/instance/old-applet/{a}/"},
+		"/": {Path: "/"},
+	}
+
+	for path, expected := range tests {
+		mapping, found := mapper.Lookup(path)
+
+		if mapping != expected || found != (expected.ID != "") {
+			t.Errorf("Expected %s to map to %+v, got %+v (%t) instead.", path, expected, mapping, found)
+		}
+	}
+
+	schema := NewTestSchema(t)
+
+	if mapping, found := mapper.LookupSection(schema, "/"); !found || mapping.ID != "com.solus-project.budgie-panel" {
+		t.Errorf("Unexpected mapping of the root section: %+v", mapping)
+	}
+
+	if id := mapper.SchemaID("com/solus-project/budgie-panel/panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}"); id != "com.solus-project.budgie-panel.panel" {
+		t.Errorf("Unexpected schema of a panel: %s", id)
+	}
+}
+
+// TestSchemaMapperChildren will test mapping the children of fixed and mounted schemas, both ways
+func TestSchemaMapperChildren(t *testing.T) {
+	set, _ := ParseGSchemaXML(GSchemaXML)
+	relocatable, _ := ParseGSchemaXML([]byte(`<schemalist>
+  <schema id="org.example.profile">
+    <child name="window" schema="org.example.window"/>
+  </schema>
+</schemalist>`))
+
+	set.Merge(relocatable)
+	set.Mount("/org/example/profiles/*/", "org.example.profile")
+	mapper := NewSchemaMapper(set)
+
+	if mapping, _ := mapper.Lookup("org/example/window"); mapping.ID != "org.example.window" || mapping.Mount != "" {
+		t.Errorf("Unexpected mapping of a fixed child: %+v", mapping)
+	}
+
+	if mapping, _ := mapper.Lookup("org/example/profiles/default/window"); mapping.ID != "org.example.window" || mapping.Mount != "/org/example/profiles/*/window/" {
+		t.Errorf("Unexpected mapping of a mounted child: %+v", mapping)
+	}
+
+	expected := []SchemaMapping{
+		{ID: "org.example.window", Path: "/org/example/window/"},
+		{ID: "org.example.window", Mount: "/org/example/profiles/*/window/"},
+	}
+
+	if paths := mapper.Paths("org.example.window"); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected paths %+v, got %+v instead.", expected, paths)
+	}
+
+	if paths := mapper.Paths("org.example"); len(paths) != 1 || paths[0].Path != "/org/example/" {
+		t.Errorf("Unexpected paths of org.example: %+v", paths)
+	}
+
+	if paths := mapper.Paths("org.example.base"); len(paths) != 0 {
+		t.Errorf("Expected no paths for an unused relocatable schema, got %+v", paths)
+	}
+}
@@ -96,6 +96,28 @@ func (sT *SchemaType) Matches(oST *SchemaType) (matches bool) {
 	}
 }
 
+// canonicalValue will return our type and the part of our value Matches compares, for Fingerprint
+func (sT *SchemaType) canonicalValue() string {
+	switch sT.Type {
+	case "bool":
+		return sT.Type + " " + strconv.FormatBool(sT.BoolVal)
+	case "uint32":
+		return sT.Type + " " + strconv.FormatUint(uint64(sT.UintVal), 10)
+	case "int32":
+		return sT.Type + " " + strconv.FormatInt(int64(sT.IntVal), 10)
+	case "float64":
+		floaty := sT.FloatVal
+
+		if floaty == 0 { // -0 and 0 Match, so they need the same value. Assigning 0 drops the sign
+			floaty = 0
+		}
+
+		return sT.Type + " " + strconv.FormatFloat(floaty, 'g', -1, 64)
+	default:
+		return sT.Type + " " + sT.Val
+	}
+}
+
 // String will convert our SchemaType back to a string
 // Note this only converts the value itself and not the key
 func (sT *SchemaType) String() string {
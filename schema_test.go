@@ -18,6 +18,10 @@
 package libdconf
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -89,6 +93,42 @@ func TestParseSchemaLine(t *testing.T) {
 	}
 }
 
+// TestEqualAndFingerprint will test Equal and Fingerprint
+func TestEqualAndFingerprint(t *testing.T) {
+	schema := NewTestSchema(t)
+	other := NewTestSchema(t)
+	other.Order = append([]string{other.Order[len(other.Order)-1]}, other.Order[:len(other.Order)-1]...) // Order should not matter
+
+	if !schema.Equal(other) || schema.Fingerprint() != other.Fingerprint() {
+		t.Fatal("Identical Schemas are not Equal or have different fingerprints")
+	}
+
+	if len(schema.Fingerprint()) != 64 {
+		t.Errorf("Unexpected fingerprint: %s", schema.Fingerprint())
+	}
+
+	kv, _ := other.GetSection(TestSectionID)
+	kv.Keys["name"], _ = NewSchemaType("'Renamed'")
+
+	if schema.Equal(other) || schema.Fingerprint() == other.Fingerprint() {
+		t.Error("Different Schemas are Equal or have the same fingerprint")
+	}
+
+	zero, _ := NewSchema("/", []byte("[/]\nzero=0.0\n"))
+	negativeZero, _ := NewSchema("/", []byte("[/]\nzero=-0.0\n"))
+
+	if !zero.Equal(negativeZero) || zero.Fingerprint() != negativeZero.Fingerprint() {
+		t.Error("Schemas with 0.0 and -0.0 are not Equal or have different fingerprints")
+	}
+
+	other = NewTestSchema(t)
+	other.Path = "/elsewhere/"
+
+	if schema.Equal(other) || schema.Fingerprint() == other.Fingerprint() {
+		t.Error("Schemas with different paths are Equal or have the same fingerprint")
+	}
+}
+
 // TestGetSection will test GetSection
 func TestGetSection(t *testing.T) {
 	var getErr error
@@ -109,6 +149,30 @@ func TestAddSection(t *testing.T) {
 	}
 }
 
+// TestClone will test that Clone is a deep copy
+func TestClone(t *testing.T) {
+	schema := NewTestSchema(t)
+	clone := schema.Clone()
+	panel := "panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}"
+
+	if !clone.Equal(schema) || strings.Join(clone.Order, ",") != strings.Join(schema.Order, ",") {
+		t.Fatal("Clone is not Equal to the original")
+	}
+
+	for section, kv := range clone.Map {
+		if strings.Join(kv.Order, ",") != strings.Join(schema.Map[section].Order, ",") {
+			t.Errorf("Clone lost the key order of %s", section)
+		}
+	}
+
+	clone.DeleteSections(TestSectionID)
+	clone.Map[panel].Keys["size"].IntVal = 99
+
+	if !schema.HasSection(TestSectionID) || schema.Map[panel].Keys["size"].IntVal == 99 {
+		t.Error("Changing the clone changed the original")
+	}
+}
+
 // TestDeleteSection will test DeleteSections
 func TestDeleteSections(t *testing.T) {
 	testID := "applet/look-at-me-i-am-special"
@@ -136,12 +200,39 @@ func TestHasSection(t *testing.T) {
 	}
 }
 
+// TestImportIntoDconf will test that ImportIntoDconf loads relative to / and ImportIntoDconfAtPath relative to our Path
+func TestImportIntoDconf(t *testing.T) {
+	dir := t.TempDir()
+	stub := "#!/bin/sh\necho \"$@\" >> " + filepath.Join(dir, "args") + "\ncat > /dev/null\n"
+
+	if writeErr := os.WriteFile(filepath.Join(dir, "dconf"), []byte(stub), 0755); writeErr != nil {
+		t.Fatalf("Failed to write the dconf stub: %s", writeErr)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	schema := NewTestSchema(t)
+
+	if importErr := schema.ImportIntoDconf(); importErr != nil {
+		t.Fatalf("Failed to import: %s", importErr)
+	}
+
+	if importErr := schema.ImportIntoDconfAtPath(); importErr != nil {
+		t.Fatalf("Failed to import at our path: %s", importErr)
+	}
+
+	if args, _ := os.ReadFile(filepath.Join(dir, "args")); string(args) != "load /\nload /com/solus-project/budgie-panel/\n" {
+		t.Errorf("Unexpected dconf commands:\n%s", args)
+	}
+}
+
 // TestMigrateSectionsWithName will test MigrateSectionsWithName
 func TestMigrateSectionsWithName(t *testing.T) {
 	oldPanelKey := "panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}"
 	newPanelKey := "moved-panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}"
 
-	TestSchema.MigrateSectionsWithName("panels/", "moved-panels/", false)
+	if migrateErr := TestSchema.MigrateSectionsWithName("panels/", "moved-panels/", false); migrateErr != nil {
+		t.Fatalf("Failed to migrate: %s", migrateErr)
+	}
 
 	if TestSchema.HasSection(oldPanelKey) { // Old panel key still exists
 		t.Errorf("Key %s still exists. Should have been moved to %s", oldPanelKey, newPanelKey)
@@ -151,3 +242,50 @@ func TestMigrateSectionsWithName(t *testing.T) {
 		t.Errorf("New key %s does not exist after migration", newPanelKey)
 	}
 }
+
+// TestMigrateSectionsWithNameExists will test that nothing is migrated when a new section already exists or is invalid
+func TestMigrateSectionsWithNameExists(t *testing.T) {
+	schema := NewTestSchema(t)
+	panel := "panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}"
+	applet := "applets/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}"
+	before := schema.Fingerprint()
+
+	if migrateErr := schema.MigrateSectionsWithName(panel, applet, true); !errors.Is(migrateErr, ErrSectionExists) {
+		t.Errorf("Expected ErrSectionExists, got %v instead.", migrateErr)
+	}
+
+	if migrateErr := schema.MigrateSectionsWithName("panels/", "bad[name]/", false); !errors.Is(migrateErr, ErrInvalidSectionName) {
+		t.Errorf("Expected ErrInvalidSectionName, got %v instead.", migrateErr)
+	}
+
+	if !schema.HasSection(panel) || schema.Fingerprint() != before {
+		t.Error("Expected the Schema to be unchanged after failing to migrate")
+	}
+
+	if migrateErr := schema.MigrateSectionsWithName("applets", "applets/old", false); migrateErr != nil { // New sections named after old ones
+		t.Errorf("Failed to migrate applets under themselves: %s", migrateErr)
+	}
+
+	if !schema.HasSection("applets/old/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}") || schema.HasSection(applet) {
+		t.Error("Failed to migrate applets to applets/old")
+	}
+}
+
+// TestMigrateSectionsWithNameBoundary will test that migrating by prefix respects directory boundaries
+func TestMigrateSectionsWithNameBoundary(t *testing.T) {
+	schema, _ := NewSchema("/org/example/", []byte("[applets]\na=1\n\n[applets/clock]\nb=2\n\n[applets-old]\nc=3\n"))
+
+	if migrateErr := schema.MigrateSectionsWithName("applets", "widgets", false); migrateErr != nil {
+		t.Fatalf("Failed to migrate: %s", migrateErr)
+	}
+
+	for _, section := range []string{"widgets", "widgets/clock", "applets-old"} {
+		if !schema.HasSection(section) {
+			t.Errorf("Expected %s to exist after migrating", section)
+		}
+	}
+
+	if schema.HasSection("widgets-old") || schema.HasSection("applets") {
+		t.Errorf("Unexpected sections after migrating: %v", schema.sortedSections())
+	}
+}
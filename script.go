@@ -0,0 +1,83 @@
+/* script.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// Scripts let a Schema be applied where only a shell is available, like when supporting someone else's machine
+// Each key is written with its own command, so running a script more than once has the same result as running it once
+
+import (
+	"strings"
+)
+
+// ShellQuote will quote the string for POSIX shells, in single quotes with any single quotes escaped
+func ShellQuote(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", `'\''`) + "'"
+}
+
+// DconfScript will return a shell script writing every key of our Schema with dconf write
+func (schema *Schema) DconfScript() string {
+	return schema.script(func(section string, key string, value string) string {
+		return "dconf write " + ShellQuote(schema.KeyPath(section, key)) + " " + ShellQuote(value)
+	})
+}
+
+// GSettingsScript will return a shell script setting every key of our Schema with gsettings set, using the mapper to find
+// the schema of each section. Relocatable schemas are set at their path, like schema.id:/path/
+// Keys with no schema are written with dconf write instead
+func (schema *Schema) GSettingsScript(mapper *SchemaMapper) string {
+	return schema.script(func(section string, key string, value string) string {
+		mapping, _ := mapper.LookupSection(schema, section)
+		gschema := mapper.source.Schema(mapping.ID)
+
+		if gschema == nil || gschema.Keys[key] == nil {
+			return "dconf write " + ShellQuote(schema.KeyPath(section, key)) + " " + ShellQuote(value)
+		}
+
+		schemaID := mapping.ID
+
+		if gschema.Relocatable() { // Needs a path, like a mounted schema or the child of one
+			schemaID += ":" + mapping.Path
+		}
+
+		return "gsettings set " + ShellQuote(schemaID) + " " + ShellQuote(key) + " " + ShellQuote(value)
+	})
+}
+
+// script will return a shell script with the line returned for each key, sorted by section and then key
+func (schema *Schema) script(line func(section string, key string, value string) string) string {
+	var script strings.Builder
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString("# Keys under " + strings.ReplaceAll(schema.basePath(), "\n", " ") + "\n")
+	script.WriteString("set -e\n")
+
+	for _, section := range schema.sortedSections() {
+		kv := schema.Map[section]
+
+		if len(kv.Keys) == 0 {
+			continue
+		}
+
+		script.WriteString("\n")
+
+		for _, key := range kv.sortedKeys() {
+			script.WriteString(line(section, key, kv.Keys[key].rawValue()) + "\n")
+		}
+	}
+
+	return script.String()
+}
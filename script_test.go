@@ -0,0 +1,112 @@
+/* script_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// RunScript will run the script with dconf and gsettings replaced by functions printing their arguments, one per line
+func RunScript(t *testing.T, script string) string {
+	if _, lookErr := exec.LookPath("sh"); lookErr != nil {
+		t.Skip("sh is not available")
+	}
+
+	stubs := "dconf() { printf '%s|' \"$@\"; echo; }\ngsettings() { printf '%s|' \"$@\"; echo; }\n"
+	output, runErr := exec.Command("sh", "-c", stubs+script).CombinedOutput()
+
+	if runErr != nil {
+		t.Fatalf("Failed to run script: %s\n%s", runErr, output)
+	}
+
+	return string(output)
+}
+
+// TestShellQuote will test quoting strings for the shell
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"":           "''",
+		"plain":      "'plain'",
+		"it's":       `'it'\''s'`,
+		"$HOME `id`": "'$HOME `id`'",
+		"'quoted'":   `''\''quoted'\'''`,
+	}
+
+	for str, expected := range tests {
+		if quoted := ShellQuote(str); quoted != expected {
+			t.Errorf("Expected %q to quote as %s, got %s instead.", str, expected, quoted)
+		}
+	}
+}
+
+// TestDconfScript will test writing keys with dconf write, and that values survive the shell
+func TestDconfScript(t *testing.T) {
+	schema, _ := NewSchema("/org/example/", []byte(`[/]
+big=1000000
+greeting="it's $HOME"
+ratio=2.5e7
+size=uint32 5
+
+[window]
+title='a "b" \\ c'
+`))
+
+	script := schema.DconfScript()
+
+	if !strings.HasPrefix(script, "#!/bin/sh\n# Keys under /org/example/\nset -e\n\n") {
+		t.Errorf("Unexpected header:\n%s", script)
+	}
+
+	expected := "dconf write '/org/example/greeting' '\"it'\\''s $HOME\"'\n"
+
+	if !strings.Contains(script, expected) {
+		t.Errorf("Expected %s in:\n%s", expected, script)
+	}
+
+	output := RunScript(t, script)
+	expectedOutput := "write|/org/example/big|1000000|\nwrite|/org/example/greeting|\"it's $HOME\"|\nwrite|/org/example/ratio|2.5e7|\nwrite|/org/example/size|uint32 5|\nwrite|/org/example/window/title|'a \"b\" \\\\ c'|\n"
+
+	if output != expectedOutput {
+		t.Errorf("Expected output:\n%s\nGot:\n%s", expectedOutput, output)
+	}
+}
+
+// TestGSettingsScript will test setting keys with gsettings set, falling back to dconf write for keys with no schema
+func TestGSettingsScript(t *testing.T) {
+	schema := NewTestSchema(t)
+	schema.DeleteSections(schema.sortedSections()[2:]...) // Root and the first applet
+	schema.Set("removed-key", &SchemaType{Type: "bool", BoolVal: true, Val: "true"})
+
+	output := RunScript(t, schema.GSettingsScript(NewSchemaMapper(NewBudgieGSchemaSet(t))))
+	expected := strings.Join([]string{
+		"set|com.solus-project.budgie-panel|dark-theme|true|",
+		"set|com.solus-project.budgie-panel|layout|'solus-fortitude'|",
+		"set|com.solus-project.budgie-panel|migration-level|1|",
+		"set|com.solus-project.budgie-panel|panels|['8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c']|",
+		"write|/com/solus-project/budgie-panel/removed-key|true|",
+		"set|com.solus-project.budgie-panel.applet:/com/solus-project/budgie-panel/applets/{7a6ad2d4-a770-11eb-a9a4-0242de3bcd68}/|alignment|'end'|",
+		"set|com.solus-project.budgie-panel.applet:/com/solus-project/budgie-panel/applets/{7a6ad2d4-a770-11eb-a9a4-0242de3bcd68}/|name|'Lock Keys Indicator'|",
+		"set|com.solus-project.budgie-panel.applet:/com/solus-project/budgie-panel/applets/{7a6ad2d4-a770-11eb-a9a4-0242de3bcd68}/|position|3|",
+	}, "\n") + "\n"
+
+	if output != expected {
+		t.Errorf("Expected output:\n%s\nGot:\n%s", expected, output)
+	}
+}
@@ -0,0 +1,269 @@
+/* selector.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// Selectors match the path of a section, like applets/clock, or of a key, like applets/clock/name, relative to the Schema's Path
+// The root section has an empty path, so its keys are just the key name
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// NewSelector will create a Selector from a glob or, when prefixed with re: (as with Modification), a regular expression
+// In globs, * matches within a single path element, ** matches any number of whole elements, ? matches a single character
+// and [...] matches a character class. So applets/*/position matches the position of every applet and **/name every name
+// Each wildcard of a glob is a capture group, as are the groups of a regular expression, for use by MoveSectionsMatching
+func NewSelector(pattern string) (selector *Selector, selectErr error) {
+	expression := ""
+
+	if strings.HasPrefix(pattern, "re:") { // Is intended to be regex
+		expression = strings.TrimPrefix(pattern, "re:")
+	} else {
+		expression = globToRegexp(strings.TrimPrefix(pattern, "/"))
+	}
+
+	compiled, compileErr := regexp.Compile(expression)
+
+	if compileErr != nil { // Not valid regexp
+		selectErr = fmt.Errorf("invalid selector %q: %w", pattern, compileErr)
+		return
+	}
+
+	selector = &Selector{
+		Pattern: pattern,
+		regexp:  compiled,
+	}
+
+	return
+}
+
+// MatchKey will check if the Selector matches the key in the provided section
+func (selector *Selector) MatchKey(section string, key string) bool {
+	return selector.regexp.MatchString(selectorKeyPath(section, key))
+}
+
+// MatchSection will check if the Selector matches the provided section
+func (selector *Selector) MatchSection(section string) bool {
+	return selector.regexp.MatchString(selectorSectionPath(section))
+}
+
+// SelectKeys will return every key the Selector matches, sorted by section and then key
+func (schema *Schema) SelectKeys(selector *Selector) (selected []SelectedKey) {
+	selected = []SelectedKey{}
+
+	for _, section := range schema.sortedSections() {
+		for _, key := range schema.Map[section].sortedKeys() {
+			if selector.MatchKey(section, key) {
+				selected = append(selected, SelectedKey{Key: key, Section: section})
+			}
+		}
+	}
+
+	return
+}
+
+// SelectSections will return every section the Selector matches, sorted
+func (schema *Schema) SelectSections(selector *Selector) (selected []string) {
+	selected = []string{}
+
+	for _, section := range schema.sortedSections() {
+		if selector.MatchSection(section) {
+			selected = append(selected, section)
+		}
+	}
+
+	return
+}
+
+// DeleteKeysMatching will delete every key the Selector matches, returning what was deleted
+// Like Delete, any section left without keys is deleted too
+func (schema *Schema) DeleteKeysMatching(selector *Selector) (deleted []SelectedKey) {
+	deleted = schema.SelectKeys(selector)
+
+	for _, selected := range deleted {
+		kv := schema.Map[selected.Section]
+		kv.DeleteKeys(selected.Key)
+
+		if len(kv.Keys) == 0 { // Like dconf, a section only exists while it has keys
+			schema.DeleteSections(selected.Section)
+		}
+	}
+
+	return
+}
+
+// DeleteSectionsMatching will delete every section the Selector matches, returning what was deleted
+func (schema *Schema) DeleteSectionsMatching(selector *Selector) (deleted []string) {
+	deleted = schema.SelectSections(selector)
+	schema.DeleteSections(deleted...)
+	return
+}
+
+// ExportMatching will return a new Schema with our Path, holding copies of the sections and keys the Selector matches
+func (schema *Schema) ExportMatching(selector *Selector) *Schema {
+	exported := &Schema{
+		Map:   make(map[string]*SchemaKV),
+		Order: []string{},
+		Path:  schema.Path,
+	}
+
+	for _, section := range schema.SelectSections(selector) { // Whole sections
+		exported.AddSection(section, schema.Map[section].Duplicate())
+	}
+
+	for _, selected := range schema.SelectKeys(selector) { // Individual keys
+		if !exported.HasSection(selected.Section) {
+			exported.AddSection(selected.Section, &SchemaKV{Order: []string{}, Keys: make(map[string]*SchemaType)})
+		}
+
+		if kv := exported.Map[selected.Section]; !kv.HasKey(selected.Key) {
+			kv.AddKey(selected.Key, schema.Map[selected.Section].Keys[selected.Key].Duplicate())
+		}
+	}
+
+	return exported
+}
+
+// ModifyKeysMatching will apply the Modification to every key the Selector matches, returning what was modified
+// If modifying any key fails, none are modified
+func (schema *Schema) ModifyKeysMatching(selector *Selector, mod Modification) (modified []SelectedKey, modErr error) {
+	working := schema.Clone() // Modify a copy so a failure leaves us untouched
+	selected := working.SelectKeys(selector)
+
+	for _, key := range selected {
+		if modErr = working.Map[key.Section].ModifyKey(key.Key, mod); modErr != nil {
+			modErr = fmt.Errorf("failed to modify %s: %w", selectorKeyPath(key.Section, key.Key), modErr)
+			return
+		}
+	}
+
+	*schema = *working // Swap in our modified Schema
+	return selected, nil
+}
+
+// MoveKeysMatching will rename every key the Selector matches to dest, within its own section, returning the keys that were moved
+// If moving any key fails, like dest already existing in a section, none are moved
+func (schema *Schema) MoveKeysMatching(selector *Selector, dest string) (moved []SelectedKey, moveErr error) {
+	working := schema.Clone() // Move within a copy so a failure leaves us untouched
+	selected := working.SelectKeys(selector)
+
+	for _, key := range selected {
+		if moveErr = working.Map[key.Section].MoveKey(key.Key, dest); moveErr != nil {
+			moveErr = fmt.Errorf("failed to move %s: %w", selectorKeyPath(key.Section, key.Key), moveErr)
+			return
+		}
+	}
+
+	*schema = *working // Swap in our Schema with the keys moved
+	return selected, nil
+}
+
+// MoveSectionsMatching will move every section the Selector matches to dest, returning the source and destination of each
+// dest may reference the capture groups of the Selector, like $1 for the first wildcard of a glob
+// If moving any section fails, like its destination already existing, none are moved
+func (schema *Schema) MoveSectionsMatching(selector *Selector, dest string) (moved [][2]string, moveErr error) {
+	working := schema.Clone() // Move within a copy so a failure leaves us untouched
+	moved = [][2]string{}
+
+	for _, section := range working.SelectSections(selector) { // Remove every source first, so sections can swap places
+		newSection := TrimSectionSlashes(selector.regexp.ReplaceAllString(selectorSectionPath(section), dest))
+
+		if newSection == "" { // Moving to the root
+			newSection = "/"
+		}
+
+		moved = append(moved, [2]string{section, newSection})
+	}
+
+	kvs := make(map[string]*SchemaKV)
+
+	for _, pair := range moved {
+		kvs[pair[0]] = working.Map[pair[0]]
+		working.DeleteSections(pair[0])
+	}
+
+	for _, pair := range moved {
+		if moveErr = working.AddSection(pair[1], kvs[pair[0]]); moveErr != nil {
+			moveErr = fmt.Errorf("failed to move %s to %s: %w", pair[0], pair[1], moveErr)
+			return nil, moveErr
+		}
+	}
+
+	sort.Slice(moved, func(i, j int) bool { return moved[i][0] < moved[j][0] })
+	*schema = *working // Swap in our Schema with the sections moved
+	return
+}
+
+// globToRegexp will convert our glob to an anchored regular expression, with a capture group per wildcard
+func globToRegexp(glob string) string {
+	var expression strings.Builder
+	expression.WriteString("^")
+
+	for index := 0; index < len(glob); index++ {
+		char := glob[index]
+
+		switch {
+		case strings.HasPrefix(glob[index:], "**/"): // Any number of whole elements, including none
+			expression.WriteString("((?:[^/]+/)*)")
+			index += 2
+		case strings.HasPrefix(glob[index:], "**"): // Anything at all
+			expression.WriteString("(.*)")
+			index++
+		case char == '*': // Anything within an element
+			expression.WriteString("([^/]+)")
+		case char == '?':
+			expression.WriteString("([^/])")
+		case char == '[' && strings.IndexByte(glob[index:], ']') > 1: // Character class
+			end := index + strings.IndexByte(glob[index:], ']')
+			class := glob[index+1 : end]
+
+			if strings.HasPrefix(class, "!") { // Negated, as in shells
+				class = "^" + class[1:]
+			}
+
+			expression.WriteString("([" + strings.ReplaceAll(class, `\`, `\\`) + "])")
+			index = end
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+
+	expression.WriteString("$")
+	return expression.String()
+}
+
+// selectorSectionPath will return the path of the section that Selectors match against, empty for the root section
+func selectorSectionPath(section string) string {
+	if section == "/" {
+		return ""
+	}
+
+	return TrimSectionSlashes(section)
+}
+
+// selectorKeyPath will return the path of the key that Selectors match against
+func selectorKeyPath(section string, key string) string {
+	if sectionPath := selectorSectionPath(section); sectionPath != "" {
+		return sectionPath + "/" + key
+	}
+
+	return key
+}
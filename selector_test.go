@@ -0,0 +1,158 @@
+/* selector_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestSelectorMatch will test glob and regex matching of sections and keys
+func TestSelectorMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		section string
+		key     string
+		matches bool
+	}{
+		{"applets/*/position", "applets/clock", "position", true},
+		{"applets/*/position", "applets/menu/extra", "position", false},
+		{"applets/*/position", "applets/clock", "name", false},
+		{"**/name", "applets/menu/extra", "name", true},
+		{"**/name", "/", "name", true},
+		{"applets/**", "applets/menu/extra", "icon", true},
+		{"applets/*", "applets-old", "", false},
+		{"applets/*", "applets/clock", "", true},
+		{"applets/[cm]*", "applets/menu", "", true},
+		{"applets/[!cm]*", "applets/menu", "", false},
+		{"applets/cloc?", "applets/clock", "", true},
+		{"re:^applets/(clock|menu)$", "applets/menu", "", true},
+		{"re:size$", "panels/main/layout", "size", true},
+	}
+
+	for _, test := range tests {
+		selector, selectErr := NewSelector(test.pattern)
+
+		if selectErr != nil {
+			t.Fatalf("Failed to create selector %q: %s", test.pattern, selectErr)
+		}
+
+		matches := selector.MatchSection(test.section)
+
+		if test.key != "" {
+			matches = selector.MatchKey(test.section, test.key)
+		}
+
+		if matches != test.matches {
+			t.Errorf("Expected %q matching [%s] %s to be %t", test.pattern, test.section, test.key, test.matches)
+		}
+	}
+
+	if _, selectErr := NewSelector("re:("); selectErr == nil {
+		t.Error("Expected an invalid regex to fail")
+	}
+}
+
+// TestDeleteMatching will test DeleteSectionsMatching and DeleteKeysMatching
+func TestDeleteMatching(t *testing.T) {
+	schema := NewTreeSchema(t)
+	selector, _ := NewSelector("applets/*")
+
+	if deleted := schema.DeleteSectionsMatching(selector); strings.Join(deleted, ",") != "applets/clock,applets/menu" {
+		t.Errorf("Unexpected sections deleted: %v", deleted)
+	}
+
+	if !schema.HasSection("applets/menu/extra") || !schema.HasSection("applets-old") {
+		t.Error("Deleted sections the selector did not match")
+	}
+
+	schema = NewTreeSchema(t)
+	selector, _ = NewSelector("**/name")
+
+	if deleted := schema.DeleteKeysMatching(selector); len(deleted) != 3 {
+		t.Errorf("Expected 3 keys deleted, got %v instead.", deleted)
+	}
+
+	if schema.HasSection("applets/menu") || !schema.HasSection("applets/clock") { // Emptied sections are deleted
+		t.Errorf("Unexpected sections after deleting names: %v", schema.sortedSections())
+	}
+}
+
+// TestModifyKeysMatching will test setting a key in every section the selector matches
+func TestModifyKeysMatching(t *testing.T) {
+	schema := NewTreeSchema(t)
+	selector, _ := NewSelector("applets/*/name")
+
+	modified, modErr := schema.ModifyKeysMatching(selector, Modification{ReplaceValues: []string{"'", "\""}})
+
+	if modErr != nil || len(modified) != 2 || schema.Map["applets/clock"].Keys["name"].Val != `"Clock"` {
+		t.Errorf("Failed to modify names: %v %v", modified, modErr)
+	}
+
+	selector, _ = NewSelector("**/size")
+
+	if _, modErr = schema.ModifyKeysMatching(selector, Modification{ReplaceValues: []string{"4", "5"}}); !errors.Is(modErr, ErrModCannotDoReplace) {
+		t.Errorf("Expected ErrModCannotDoReplace, got %v instead.", modErr)
+	}
+}
+
+// TestMoveMatching will test MoveSectionsMatching and MoveKeysMatching
+func TestMoveMatching(t *testing.T) {
+	schema := NewTreeSchema(t)
+	selector, _ := NewSelector("applets/*")
+
+	moved, moveErr := schema.MoveSectionsMatching(selector, "old/$1")
+
+	if moveErr != nil || len(moved) != 2 || !schema.HasSection("old/clock") || !schema.HasSection("old/menu") || schema.HasSection("applets/clock") {
+		t.Errorf("Failed to move sections: %v %v %v", moved, moveErr, schema.sortedSections())
+	}
+
+	selector, _ = NewSelector("old/*")
+
+	if _, moveErr = schema.MoveSectionsMatching(selector, "applets-old"); !errors.Is(moveErr, ErrSectionExists) || !schema.HasSection("old/clock") {
+		t.Errorf("Expected ErrSectionExists leaving the Schema untouched, got %v instead.", moveErr)
+	}
+
+	selector, _ = NewSelector("old/*/name")
+
+	if moved, moveErr := schema.MoveKeysMatching(selector, "title"); moveErr != nil || len(moved) != 2 || !schema.Map["old/menu"].HasKey("title") {
+		t.Errorf("Failed to move keys: %v %v", moved, moveErr)
+	}
+}
+
+// TestExportMatching will test ExportMatching
+func TestExportMatching(t *testing.T) {
+	schema := NewTreeSchema(t)
+	selector, _ := NewSelector("**/name")
+	exported := schema.ExportMatching(selector)
+
+	if exported.Path != schema.Path || strings.Join(exported.sortedSections(), ",") != "applets-old,applets/clock,applets/menu" {
+		t.Errorf("Unexpected export: %v", exported.sortedSections())
+	}
+
+	if exported.Map["applets/clock"].HasKey("position") {
+		t.Error("Exported a key the selector did not match")
+	}
+
+	exported.Map["applets/clock"].Keys["name"].Val = "'Changed'"
+
+	if schema.Map["applets/clock"].Keys["name"].Val == "'Changed'" {
+		t.Error("Changing the export changed the original")
+	}
+}
@@ -0,0 +1,96 @@
+/* stale.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// FindStale will find every section and key of the Schema with no installed GSettings schema behind it
+// Sections are mapped to GSettings schemas with a SchemaMapper. Entries are of the same kinds as Validate's issues, either
+// ValidationUnknownKey, ValidationUnknownSchema or ValidationUnownedRelocatable. Nothing is deleted, see StaleReport's Delete
+func FindStale(schema *Schema, source SchemaSource) *StaleReport {
+	report := &StaleReport{Entries: []StaleEntry{}}
+	mapper := NewSchemaMapper(source)
+
+	for _, section := range schema.sortedSections() {
+		dir := schema.KeyPath(section, "")
+		gschema := source.Schema(mapper.SchemaID(dir))
+
+		if gschema == nil {
+			entry := StaleEntry{Kind: ValidationUnknownSchema, Path: dir, Section: section}
+
+			if entry.Owner = mapper.owner(dir); entry.Owner != "" {
+				entry.Kind = ValidationUnownedRelocatable
+			}
+
+			report.Entries = append(report.Entries, entry)
+			continue
+		}
+
+		for _, key := range schema.Map[section].sortedKeys() {
+			if _, exists := gschema.Keys[key]; !exists {
+				report.Entries = append(report.Entries, StaleEntry{Key: key, Kind: ValidationUnknownKey, Owner: gschema.ID, Path: dir + key, Section: section})
+			}
+		}
+	}
+
+	return report
+}
+
+// Delete will delete every stale section and key of the report from the Schema, along with any section left without keys
+// The full dconf path of each key and section deleted is returned, sections with a trailing slash. Anything no longer
+// in the Schema is skipped
+func (report *StaleReport) Delete(schema *Schema) (deleted []string) {
+	deleted = []string{}
+
+	for _, entry := range report.Entries {
+		kv, exists := schema.Map[entry.Section]
+
+		if !exists { // Already deleted
+			continue
+		}
+
+		if entry.Key == "" {
+			schema.DeleteSections(entry.Section)
+			deleted = append(deleted, schema.KeyPath(entry.Section, ""))
+			continue
+		}
+
+		if !kv.HasKey(entry.Key) {
+			continue
+		}
+
+		kv.DeleteKeys(entry.Key)
+		deleted = append(deleted, schema.KeyPath(entry.Section, entry.Key))
+
+		if len(kv.Keys) == 0 { // Nothing left
+			schema.DeleteSections(entry.Section)
+			deleted = append(deleted, schema.KeyPath(entry.Section, ""))
+		}
+	}
+
+	return
+}
+
+// Sections will return the sections of the report of the kind, one of the Validation constants
+func (report *StaleReport) Sections(kind string) (sections []string) {
+	for _, entry := range report.Entries {
+		if entry.Kind == kind && (len(sections) == 0 || sections[len(sections)-1] != entry.Section) {
+			sections = append(sections, entry.Section)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,103 @@
+/* stale_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFindStale will test finding and deleting stale sections and keys
+func TestFindStale(t *testing.T) {
+	set := NewBudgieGSchemaSet(t)
+
+	if report := FindStale(NewTestSchema(t), set); len(report.Entries) != 0 {
+		t.Errorf("Expected nothing stale in our example, got %+v", report.Entries)
+	}
+
+	schema, _ := NewSchema("/com/solus-project/", []byte(`[budgie-desktop/old]
+enabled=true
+
+[budgie-panel]
+dark-theme=true
+removed-key=1
+
+[budgie-panel/applets/{abc}]
+name='Clock'
+removed-key=1
+
+[budgie-panel/applets/{def}]
+removed-key=1
+
+[budgie-panel/instance/old-applet/{abc}]
+enabled=true
+
+[budgie-panel/panels/{abc}]
+removed-key=1
+`))
+
+	report := FindStale(schema, set)
+
+	expected := []StaleEntry{
+		{Kind: ValidationUnknownSchema, Path: "/com/solus-project/budgie-desktop/old/", Section: "budgie-desktop/old"},
+		{Key: "removed-key", Kind: ValidationUnknownKey, Owner: "com.solus-project.budgie-panel", Path: "/com/solus-project/budgie-panel/removed-key", Section: "budgie-panel"},
+		{Key: "removed-key", Kind: ValidationUnknownKey, Owner: "com.solus-project.budgie-panel.applet", Path: "/com/solus-project/budgie-panel/applets/{abc}/removed-key", Section: "budgie-panel/applets/{abc}"},
+		{Key: "removed-key", Kind: ValidationUnknownKey, Owner: "com.solus-project.budgie-panel.applet", Path: "/com/solus-project/budgie-panel/applets/{def}/removed-key", Section: "budgie-panel/applets/{def}"},
+		{Kind: ValidationUnownedRelocatable, Owner: "com.solus-project.budgie-panel", Path: "/com/solus-project/budgie-panel/instance/old-applet/{abc}/", Section: "budgie-panel/instance/old-applet/{abc}"},
+		{Kind: ValidationUnownedRelocatable, Owner: "com.solus-project.budgie-panel", Path: "/com/solus-project/budgie-panel/panels/{abc}/", Section: "budgie-panel/panels/{abc}"},
+	}
+
+	if !reflect.DeepEqual(report.Entries, expected) {
+		t.Fatalf("Expected %+v, got %+v instead.", expected, report.Entries)
+	}
+
+	if sections := report.Sections(ValidationUnknownKey); !reflect.DeepEqual(sections, []string{"budgie-panel", "budgie-panel/applets/{abc}", "budgie-panel/applets/{def}"}) {
+		t.Errorf("Unexpected sections with unknown keys: %v", sections)
+	}
+
+	deleted := report.Delete(schema)
+	expectedDeleted := []string{
+		"/com/solus-project/budgie-desktop/old/",
+		"/com/solus-project/budgie-panel/removed-key",
+		"/com/solus-project/budgie-panel/applets/{abc}/removed-key",
+		"/com/solus-project/budgie-panel/applets/{def}/removed-key",
+		"/com/solus-project/budgie-panel/applets/{def}/", // Left without keys
+		"/com/solus-project/budgie-panel/instance/old-applet/{abc}/",
+		"/com/solus-project/budgie-panel/panels/{abc}/",
+	}
+
+	if !reflect.DeepEqual(deleted, expectedDeleted) {
+		t.Errorf("Expected %v to be deleted, got %v instead.", expectedDeleted, deleted)
+	}
+
+	if deleted = report.Delete(schema); len(deleted) != 0 { // Nothing left to delete
+		t.Errorf("Expected nothing to be deleted again, got %v", deleted)
+	}
+
+	if sections := schema.sortedSections(); !reflect.DeepEqual(sections, []string{"budgie-panel", "budgie-panel/applets/{abc}"}) {
+		t.Errorf("Unexpected sections after deleting: %v", sections)
+	}
+
+	if schema.Map["budgie-panel"].HasKey("removed-key") || !schema.Map["budgie-panel"].HasKey("dark-theme") {
+		t.Errorf("Unexpected keys after deleting: %v", schema.Map["budgie-panel"].Order)
+	}
+
+	if report := FindStale(schema, set); len(report.Entries) != 0 {
+		t.Errorf("Expected nothing stale after deleting, got %+v", report.Entries)
+	}
+}
@@ -17,6 +17,220 @@
 
 package libdconf
 
+import (
+	"regexp"
+)
+
+// Backend reads and writes keys by their full dconf key path, like a Schema or DconfBackend
+type Backend interface {
+	Get(keyPath string) (*SchemaType, error)
+	Set(keyPath string, t *SchemaType) error
+}
+
+// DconfBackend is a Backend reading and writing the live dconf database with the dconf command
+type DconfBackend struct{}
+
+// GoGenOptions control the Go bindings written by GenerateGo
+type GoGenOptions struct {
+	Package    string   // Package of the generated file, defaults to gsettings
+	Schemas    []string // IDs of the schemas to generate, along with their children. Defaults to every schema
+	TrimPrefix string   // Prefix to trim from schema and enum IDs when naming types, like com.solus-project.
+}
+
+// GSchema is a GSettings schema, as declared by a <schema> in a gschema.xml file
+type GSchema struct {
+	Children      []GSchemaChild         // Child schemas, each at a directory of the same name under our Path
+	Extends       string                 // ID of the schema we extend, whose keys and children we inherit
+	GettextDomain string                 // Domain to translate our summaries and descriptions with
+	ID            string                 // ID, like com.solus-project.budgie-panel
+	Keys          map[string]*GSchemaKey // Keys by name, including any inherited from the schema we extend
+	Overrides     map[string]string      // Defaults we override for keys inherited from the schema we extend, by key name
+	Path          string                 // Path, like /com/solus-project/budgie-panel/. Empty for relocatable schemas
+}
+
+// GSchemaChild is a child schema of a GSchema
+type GSchemaChild struct {
+	Name   string // Name of the child, the directory it is at under the parent's Path
+	Schema string // ID of the child's schema
+}
+
+// GSchemaEnum is an <enum> or <flags> of a gschema.xml file
+type GSchemaEnum struct {
+	Flags  bool               // Whether this is flags rather than an enum
+	ID     string             // ID, like com.solus-project.budgie-panel.Alignment
+	Values []GSchemaEnumValue // Nicks and their values, in the order declared
+}
+
+// GSchemaEnumValue is a nick and its numeric value in a GSchemaEnum
+type GSchemaEnumValue struct {
+	Nick  string // Nick, like start
+	Value int32  // Numeric value of the nick
+}
+
+// GSchemaKey is a key of a GSchema
+type GSchemaKey struct {
+	Aliases     map[string]string // Alternative values which are accepted in place of their target
+	Choices     []string          // Values a string key is restricted to, if any
+	Default     string            // Default value, in the GVariant text format
+	Description string            // Longer description, if any
+	Enum        string            // ID of the GSchemaEnum an enum key takes a nick of
+	Flags       string            // ID of the GSchemaEnum a flags key takes an array of nicks of
+	Name        string            // Name, like dark-theme
+	Range       *GSchemaRange     // Range a numeric key is restricted to, if any
+	Summary     string            // One line summary, if any
+	Type        string            // GVariant type string, like b or as. s for enum keys and as for flags keys
+}
+
+// GSchemaRange is the range of values a numeric GSchemaKey is restricted to, inclusive
+type GSchemaRange struct {
+	Max string // Maximum, in the GVariant text format
+	Min string // Minimum, in the GVariant text format
+}
+
+// GSchemaMount mounts a relocatable GSettings schema at every dconf directory matching a glob, see GSchemaSet's Mount
+type GSchemaMount struct {
+	Path   string // Glob of the directories, like /com/solus-project/budgie-panel/applets/*/
+	Schema string // ID of the relocatable schema
+}
+
+// GSchemaSet is a set of GSettings schemas, along with the enums and flags their keys use
+type GSchemaSet struct {
+	Enums   map[string]*GSchemaEnum // Enums and flags by ID
+	Schemas map[string]*GSchema     // Schemas by ID
+
+	mounts []GSchemaMount // Where our relocatable schemas are mounted
+}
+
+// SchemaMapper maps between dconf paths and the GSettings schemas at them, see NewSchemaMapper
+type SchemaMapper struct {
+	fixed    map[string]string          // IDs of schemas with a fixed path, by their path
+	mounts   []schemaMapperMount        // Mounts of our source, in order
+	paths    map[string][]SchemaMapping // Where each schema is, by ID, built on first use
+	resolved map[string]SchemaMapping   // Mappings we have already resolved, by path
+	source   SchemaSource               // Source of the schemas we map
+}
+
+// SchemaMapping is a GSettings schema at a dconf path, see SchemaMapper
+type SchemaMapping struct {
+	ID    string // ID of the schema
+	Mount string // Glob of the mount the schema is at, like /com/solus-project/budgie-panel/applets/*/. Empty for fixed paths
+	Path  string // Full dconf path, like /com/solus-project/budgie-panel/. Empty when only the Mount is known
+}
+
+// SchemaSource is a source of GSettings schemas to check dconf paths and values against, like a GSchemaSet
+type SchemaSource interface {
+	Enum(id string) *GSchemaEnum // Enum or flags with the ID, nil if there is none
+	IDs() []string               // Sorted IDs of every schema
+	Mounts() []GSchemaMount      // Where relocatable schemas are mounted
+	Schema(id string) *GSchema   // Schema with the ID, nil if there is none
+}
+
+// PrunedKey is a key removed by PruneDefaults, as it was set to its default
+type PrunedKey struct {
+	Default string // Default the key was set to, from the GSettings schema or an override
+	Key     string // Name of the key
+	Schema  string // ID of the GSettings schema of the section
+	Section string // Section the key was in
+}
+
+// PruneReport is what PruneDefaults removed from a Schema
+type PruneReport struct {
+	Pruned   []PrunedKey // Every key removed, sorted by section and then key
+	Sections []string    // Sections removed as none of their keys remained, sorted
+}
+
+// StaleEntry is a section or key of a Schema with no installed GSettings schema behind it, found by FindStale
+type StaleEntry struct {
+	Key     string // Key, empty when the whole section is stale
+	Kind    string // One of ValidationUnknownKey, ValidationUnknownSchema or ValidationUnownedRelocatable
+	Owner   string // ID of the schema of the section for unknown keys, or of its nearest parent for unowned relocatable paths
+	Path    string // Full dconf path of the key or section
+	Section string // Section of the key, or the stale section
+}
+
+// StaleReport is every stale section and key of a Schema, see FindStale
+type StaleReport struct {
+	Entries []StaleEntry // Stale sections and keys, sorted by section and then key
+}
+
+// ValidationIssue is a problem with a section or key of a Schema, found by Validate
+type ValidationIssue struct {
+	Key     string // Key the problem is with, empty for a section with no GSettings schema
+	Kind    string // One of the Validation constants
+	Message string // Human readable description of the problem
+	Path    string // Full dconf path of the key or section
+	Schema  string // ID of the GSettings schema of the section, empty if there is none
+	Section string // Section the problem is in
+}
+
+// ValidationReport is the outcome of validating a Schema against a SchemaSource, see Validate
+type ValidationReport struct {
+	Issues   []ValidationIssue // Every problem found, sorted by section and then key
+	Sections map[string]string // ID of the GSettings schema of each section. Sections with no schema are not included
+}
+
+// ImportPlan describes what importing a Schema into dconf will change, see Schema's Plan
+type ImportPlan struct {
+	Path    string       // Path the Schema will be imported into
+	Changes []PlanChange // Every key in the Schema and what importing will do to it
+
+	live   []byte // The live state we planned against
+	schema string // The Schema we planned with
+}
+
+// PlanChange is what an import will do to a single key
+type PlanChange struct {
+	Action  string // One of the PlanAction constants
+	Key     string // Key being imported
+	New     string // Value the key will have after importing
+	Old     string // Value the key has now, empty if the key does not exist
+	Section string // Section of the key
+}
+
+// Migration is a declarative, ordered set of steps to apply to a Schema
+// Migrations are typically loaded from TOML via NewMigration
+type Migration struct {
+	Description string          `toml:"description"` // Human readable description of what this migration does
+	Steps       []MigrationStep `toml:"step"`        // Our ordered steps
+}
+
+// MigrationRegistry is a set of numbered migrations, applied based on the level stored in a Schema
+type MigrationRegistry struct {
+	LevelKey     string             // Key we read and write the current migration level from, defaults to migration-level
+	LevelSection string             // Section the LevelKey is in, defaults to / (the root of the Schema)
+	Migrations   map[int]*Migration // Our migrations, by level
+}
+
+// AppliedMigration is the outcome of a single migration applied by a MigrationRegistry
+type AppliedMigration struct {
+	Level   int                   // Level of the migration
+	Reports []MigrationStepReport // Report for each step of the migration
+}
+
+// MigrationStep is a single operation within a Migration
+// Which fields are used depends on the Action, see the MigrationAction constants
+type MigrationStep struct {
+	Modification // Value and ReplaceValues, used by set-key and replace
+
+	Action   string   `toml:"action"`   // What this step does
+	Dest     string   `toml:"dest"`     // Destination section for migrate-sections, destination key for move-key
+	Exact    bool     `toml:"exact"`    // Only migrate the section if it matches Source exactly
+	Key      string   `toml:"key"`      // Key to set or replace in
+	Keys     []string `toml:"keys"`     // Keys to delete
+	Prefix   bool     `toml:"prefix"`   // Delete sections by prefix rather than by exact name
+	Section  string   `toml:"section"`  // Section the key operations apply to
+	Sections []string `toml:"sections"` // Sections to delete
+	Source   string   `toml:"source"`   // Source section for migrate-sections, source key for move-key
+}
+
+// MigrationStepReport is the outcome of applying a single MigrationStep
+type MigrationStepReport struct {
+	Index    int      // Index of the step in the Migration
+	Action   string   // Action of the step
+	Sections []string // Sections the step changed
+	Err      error    // Error if the step failed
+}
+
 // Modification defines a desired change to a SchemaType
 type Modification struct {
 	// ReplaceValues is an array of RegExp (regular expression) for search to the replacement value
@@ -28,11 +242,88 @@ type Modification struct {
 	Value string `toml:"value"`
 }
 
+// DuplicateDecision is how a section or key which appeared more than once was resolved while parsing
+type DuplicateDecision struct {
+	FirstLine  int    // Line the section or key first appeared on
+	Key        string // Key, empty for a duplicate section
+	Line       int    // Line the duplicate appeared on
+	Resolution string // Resolution applied, one of DuplicatePolicyFirstWins, DuplicatePolicyLastWins or DuplicatePolicyMerge
+	Section    string // Section, or the section of the key
+}
+
+// ParseOptions control how NewSchemaWithOptions parses a keyfile
+type ParseOptions struct {
+	// Duplicates is one of our DuplicatePolicy constants, for sections and keys which appear more than once, like when concatenating fragments
+	// By default, duplicate sections are merged and the first of any duplicate key wins, with a warning for each. Any other value is an error
+	Duplicates string
+
+	// FileName is used in any ParseError, to make them easier to track down
+	FileName string
+
+	// Lossless will retain comments, blank lines, ordering and the original text of each value
+	// String then reproduces the content exactly for an unmodified Schema, and only rewrites what changed otherwise
+	Lossless bool
+
+	// MaxLineLength is the maximum length of a line in bytes, excluding the newline. 0 means no limit
+	MaxLineLength int
+
+	// MaxSections is the maximum number of sections. 0 means no limit
+	MaxSections int
+
+	// MaxValueSize is the maximum size of a value in bytes. 0 means no limit
+	MaxValueSize int
+
+	// Strict will fail parsing with ParseErrors if there are any problems with the content
+	// By default, problematic lines are skipped and reported in the Schema's Warnings
+	// Exceeding any of our limits always fails parsing, regardless of Strict
+	Strict bool
+}
+
+// ParseError is a problem with a specific line of a keyfile
+type ParseError struct {
+	Column   int    // Column of the problem, starting from 1
+	Err      error  // Underlying error, like ErrParseInvalidValue
+	FileName string // Name of the file, from our ParseOptions
+	Key      string // Key the problem is with, if any
+	Line     int    // Line of the problem, starting from 1
+	Section  string // Section the problem is in, if any
+}
+
+// ParseErrors is a list of ParseError, returned when parsing strictly
+type ParseErrors []*ParseError
+
+// SelectedKey is a key in a section, selected by a Selector
+type SelectedKey struct {
+	Key     string // Name of the key
+	Section string // Section the key is in
+}
+
+// Selector selects sections and keys by their path relative to the Schema's Path, see NewSelector
+type Selector struct {
+	Pattern string // Pattern the Selector was created from
+
+	regexp *regexp.Regexp // Pattern compiled to an anchored regular expression, for globs
+}
+
+// Query finds sections or keys of a Schema by their path and values, see NewQuery
+type Query struct {
+	Source string // Text the Query was parsed from
+
+	root queryNode // Parsed expression
+}
+
 // Schema is a map of paths to key values
 type Schema struct {
 	Order []string             // Our fixed order
 	Map   map[string]*SchemaKV // Our Map of Sections (like com/solus-project/budgie-desktop/instance/icon-tasklist)
 	Path  string               // Path for the Schema
+
+	Duplicates []DuplicateDecision // How sections and keys which appeared more than once were resolved while parsing
+	SchemaIDs  map[string]string   // GSettings schema ID of each section, when imported by schema ID, see ParseGSettingsList
+	Warnings   ParseErrors         // Problems found while parsing, which we skipped over
+
+	layout *schemaLayout // Original layout, if parsed losslessly
+	tree   *sectionIndex // Index of our sections as directories, built when first needed, see index
 }
 
 // SchemaKV is a map of keys to our SchemaType
@@ -41,6 +332,17 @@ type SchemaKV struct {
 	Keys  map[string]*SchemaType // Our Map of Keys in each Section
 }
 
+// Transaction batches mutations of a Schema, which are only applied to the Schema on Commit
+// Every mutation is journaled, so it can be undone and redone before committing
+type Transaction struct {
+	began   string         // Fingerprint of the Schema when we began
+	closed  bool           // Whether we have been committed or rolled back
+	journal []journalEntry // Mutations we have applied, oldest first
+	redo    []journalEntry // Mutations we have undone, most recently undone last
+	schema  *Schema        // Schema we commit to
+	working *Schema        // Copy of the Schema our mutations are applied to
+}
+
 // SchemaType is our defined type
 // This type will have a defined Type (e.g. "bool") as Type and the designated type set
 // This allows us to perform less type checking and reflection during marshal and unmarshalling
@@ -0,0 +1,447 @@
+/* toml.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// This file implements the small subset of TOML we need for our data files (like migrations)
+// Supported: comments, key = value pairs, [tables], [[arrays of tables]], strings, integers, booleans and arrays
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// tomlTable is a parsed TOML table. Values are string, int64, bool, []interface{} or []tomlTable
+type tomlTable map[string]interface{}
+
+// parseTOML will parse the provided content into a tomlTable
+func parseTOML(content []byte) (root tomlTable, parseErr error) {
+	root = make(tomlTable)
+	current := root // Table we are currently adding keys to
+
+	lines := strings.Split(string(content), "\n")
+
+	for index := 0; index < len(lines); index++ {
+		lineNum := index + 1
+		line := strings.TrimSpace(stripTOMLComment(lines[index]))
+
+		if line == "" { // Empty or comment-only line
+			continue
+		}
+
+		if strings.HasPrefix(line, "[[") { // Array of tables
+			if !strings.HasSuffix(line, "]]") {
+				parseErr = fmt.Errorf("%w: line %d: unterminated table array header", ErrTOMLSyntax, lineNum)
+				return
+			}
+
+			name := strings.TrimSpace(line[2 : len(line)-2])
+			existing, exists := root[name]
+			tables, isTables := existing.([]tomlTable)
+
+			if exists && !isTables { // Already defined as something else
+				parseErr = fmt.Errorf("%w: line %d: %s is not an array of tables", ErrTOMLSyntax, lineNum, name)
+				return
+			}
+
+			current = make(tomlTable)
+			root[name] = append(tables, current)
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") { // Table
+			if !strings.HasSuffix(line, "]") {
+				parseErr = fmt.Errorf("%w: line %d: unterminated table header", ErrTOMLSyntax, lineNum)
+				return
+			}
+
+			name := strings.TrimSpace(line[1 : len(line)-1])
+
+			if _, exists := root[name]; exists { // Tables may only be defined once
+				parseErr = fmt.Errorf("%w: line %d: table %s defined more than once", ErrTOMLSyntax, lineNum, name)
+				return
+			}
+
+			current = make(tomlTable)
+			root[name] = current
+			continue
+		}
+
+		keyValArr := strings.SplitN(line, "=", 2) // Split between key and value
+
+		if len(keyValArr) != 2 { // Not a key = value
+			parseErr = fmt.Errorf("%w: line %d: expected key = value", ErrTOMLSyntax, lineNum)
+			return
+		}
+
+		key := strings.TrimSpace(keyValArr[0])
+		rawVal := strings.TrimSpace(keyValArr[1])
+
+		if unquoted, unquoteErr := strconv.Unquote(key); unquoteErr == nil { // Quoted key
+			key = unquoted
+		}
+
+		if key == "" {
+			parseErr = fmt.Errorf("%w: line %d: empty key", ErrTOMLSyntax, lineNum)
+			return
+		}
+
+		for strings.HasPrefix(rawVal, "[") && !tomlArrayClosed(rawVal) && index+1 < len(lines) { // Multi-line array
+			index++
+			rawVal += " " + strings.TrimSpace(stripTOMLComment(lines[index]))
+		}
+
+		if _, exists := current[key]; exists { // Keys may only be defined once per table
+			parseErr = fmt.Errorf("%w: line %d: key %s defined more than once", ErrTOMLSyntax, lineNum, key)
+			return
+		}
+
+		val, rest, valErr := parseTOMLValue(rawVal)
+
+		if valErr == nil && strings.TrimSpace(rest) != "" { // Trailing garbage after our value
+			valErr = fmt.Errorf("unexpected %q after value", rest)
+		}
+
+		if valErr != nil {
+			parseErr = fmt.Errorf("%w: line %d: %s", ErrTOMLSyntax, lineNum, valErr)
+			return
+		}
+
+		current[key] = val
+	}
+
+	return
+}
+
+// parseTOMLValue will parse a single value from the start of raw, returning what remains
+func parseTOMLValue(raw string) (val interface{}, rest string, parseErr error) {
+	raw = strings.TrimSpace(raw)
+
+	switch {
+	case raw == "":
+		parseErr = fmt.Errorf("missing value")
+	case raw[0] == '"': // Basic string, supports escapes
+		end := 1
+		for ; end < len(raw) && raw[end] != '"'; end++ {
+			if raw[end] == '\\' { // Skip whatever is escaped
+				end++
+			}
+		}
+
+		if end >= len(raw) {
+			parseErr = fmt.Errorf("unterminated string")
+			return
+		}
+
+		val, parseErr = strconv.Unquote(raw[:end+1])
+		rest = raw[end+1:]
+	case raw[0] == '\'': // Literal string, no escapes
+		end := strings.IndexByte(raw[1:], '\'')
+
+		if end == -1 {
+			parseErr = fmt.Errorf("unterminated string")
+			return
+		}
+
+		val = raw[1 : end+1]
+		rest = raw[end+2:]
+	case raw[0] == '[': // Array
+		arr := []interface{}{}
+		rest = strings.TrimSpace(raw[1:])
+
+		for !strings.HasPrefix(rest, "]") {
+			var item interface{}
+			if item, rest, parseErr = parseTOMLValue(rest); parseErr != nil {
+				return
+			}
+
+			arr = append(arr, item)
+			rest = strings.TrimSpace(rest)
+
+			if strings.HasPrefix(rest, ",") { // More items (or a trailing comma)
+				rest = strings.TrimSpace(rest[1:])
+			} else if !strings.HasPrefix(rest, "]") {
+				parseErr = fmt.Errorf("expected , or ] in array")
+				return
+			}
+		}
+
+		val = arr
+		rest = rest[1:]
+	default: // Boolean or integer
+		end := strings.IndexAny(raw, ",] \t")
+		if end == -1 {
+			end = len(raw)
+		}
+
+		word := raw[:end]
+		rest = raw[end:]
+
+		if word == "true" || word == "false" {
+			val = word == "true"
+		} else if inty, intErr := strconv.ParseInt(strings.ReplaceAll(word, "_", ""), 10, 64); intErr == nil {
+			val = inty
+		} else {
+			parseErr = fmt.Errorf("unsupported value %q", word)
+		}
+	}
+
+	return
+}
+
+// decodeTOML will decode the provided table into out, which must be a pointer to a struct
+// Fields are matched by their toml tag, embedded structs are decoded into as if their fields were our own
+func decodeTOML(table tomlTable, out interface{}) error {
+	fields := make(map[string]reflect.Value)
+	collectTOMLFields(reflect.ValueOf(out).Elem(), fields)
+
+	for key, val := range table {
+		field, known := fields[key]
+
+		if !known { // Catch typos rather than silently ignoring them
+			return fmt.Errorf("%w: unknown key %s", ErrTOMLSyntax, key)
+		}
+
+		if decodeErr := decodeTOMLValue(val, field); decodeErr != nil {
+			return fmt.Errorf("%w: key %s: %s", ErrTOMLSyntax, key, decodeErr)
+		}
+	}
+
+	return nil
+}
+
+// encodeTOML will encode the provided struct (or pointer to one) as TOML
+// Fields with zero values are omitted, slices of structs are written as arrays of tables
+func encodeTOML(in interface{}) []byte {
+	var builder strings.Builder
+	encodeTOMLTable(&builder, reflect.Indirect(reflect.ValueOf(in)), "")
+	return []byte(builder.String())
+}
+
+// encodeTOMLTable will write the fields of the struct, with any arrays of tables after our own keys
+func encodeTOMLTable(builder *strings.Builder, structVal reflect.Value, name string) {
+	fields := make(map[string]reflect.Value)
+	collectTOMLFields(structVal, fields)
+
+	keys := []string{}
+	tables := []string{}
+
+	for key, field := range fields {
+		if field.IsZero() || (field.Kind() == reflect.Slice && field.Len() == 0) { // Nothing worth writing
+			continue
+		}
+
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct {
+			tables = append(tables, key)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	sort.Strings(tables)
+
+	if name != "" {
+		builder.WriteString("[[" + name + "]]\n")
+	}
+
+	for _, key := range keys {
+		builder.WriteString(key + " = " + encodeTOMLValue(fields[key]) + "\n")
+	}
+
+	for _, table := range tables {
+		slice := fields[table]
+
+		for index := 0; index < slice.Len(); index++ {
+			builder.WriteString("\n")
+			encodeTOMLTable(builder, slice.Index(index), table)
+		}
+	}
+}
+
+// encodeTOMLValue will encode a single string, boolean, integer or array value
+func encodeTOMLValue(val reflect.Value) string {
+	switch val.Kind() {
+	case reflect.String:
+		return quoteTOMLString(val.String())
+	case reflect.Bool:
+		return strconv.FormatBool(val.Bool())
+	case reflect.Slice:
+		items := []string{}
+
+		for index := 0; index < val.Len(); index++ {
+			items = append(items, encodeTOMLValue(val.Index(index)))
+		}
+
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return strconv.FormatInt(val.Int(), 10)
+	}
+}
+
+// quoteTOMLString will quote the string as a TOML basic string
+// We cannot use strconv.Quote since TOML does not support all of Go's escapes
+func quoteTOMLString(str string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+
+	for _, r := range str {
+		switch {
+		case r == '"' || r == '\\':
+			builder.WriteRune('\\')
+			builder.WriteRune(r)
+		case r == '\n':
+			builder.WriteString("\\n")
+		case r == '\t':
+			builder.WriteString("\\t")
+		case r < 0x20 || r == 0x7f: // Other control characters
+			builder.WriteString(fmt.Sprintf("\\u%04x", r))
+		default:
+			builder.WriteRune(r)
+		}
+	}
+
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+// collectTOMLFields will collect the settable fields of the struct by their toml tag
+func collectTOMLFields(structVal reflect.Value, fields map[string]reflect.Value) {
+	structType := structVal.Type()
+
+	for index := 0; index < structType.NumField(); index++ {
+		fieldType := structType.Field(index)
+
+		if fieldType.Anonymous && fieldType.Type.Kind() == reflect.Struct { // Embedded struct, flatten it
+			collectTOMLFields(structVal.Field(index), fields)
+			continue
+		}
+
+		if tag := fieldType.Tag.Get("toml"); tag != "" && tag != "-" {
+			fields[tag] = structVal.Field(index)
+		}
+	}
+}
+
+// decodeTOMLValue will set the field to the provided parsed TOML value
+func decodeTOMLValue(val interface{}, field reflect.Value) error {
+	switch field.Kind() {
+	case reflect.String:
+		str, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("expected a string")
+		}
+
+		field.SetString(str)
+	case reflect.Bool:
+		b, ok := val.(bool)
+		if !ok {
+			return fmt.Errorf("expected a boolean")
+		}
+
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := val.(int64)
+		if !ok || field.OverflowInt(i) {
+			return fmt.Errorf("expected an integer")
+		}
+
+		field.SetInt(i)
+	case reflect.Slice:
+		if tables, isTables := val.([]tomlTable); isTables && field.Type().Elem().Kind() == reflect.Struct { // Array of tables
+			slice := reflect.MakeSlice(field.Type(), len(tables), len(tables))
+
+			for index, table := range tables {
+				if decodeErr := decodeTOML(table, slice.Index(index).Addr().Interface()); decodeErr != nil {
+					return decodeErr
+				}
+			}
+
+			field.Set(slice)
+			return nil
+		}
+
+		items, ok := val.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected an array")
+		}
+
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+
+		for index, item := range items {
+			if decodeErr := decodeTOMLValue(item, slice.Index(index)); decodeErr != nil {
+				return decodeErr
+			}
+		}
+
+		field.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
+
+// stripTOMLComment will remove any # comment from the line, ignoring any # inside of strings
+func stripTOMLComment(line string) string {
+	var quote byte
+
+	for index := 0; index < len(line); index++ {
+		c := line[index]
+
+		switch {
+		case quote != 0 && c == '\\' && quote == '"': // Escaped character in a basic string
+			index++
+		case quote != 0 && c == quote: // End of string
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''): // Start of string
+			quote = c
+		case quote == 0 && c == '#': // Comment
+			return line[:index]
+		}
+	}
+
+	return line
+}
+
+// tomlArrayClosed will check if the brackets in the provided raw array value are balanced
+func tomlArrayClosed(raw string) bool {
+	depth := 0
+	var quote byte
+
+	for index := 0; index < len(raw); index++ {
+		c := raw[index]
+
+		switch {
+		case quote != 0 && c == '\\' && quote == '"':
+			index++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '[':
+			depth++
+		case quote == 0 && c == ']':
+			depth--
+		}
+	}
+
+	return depth <= 0
+}
@@ -0,0 +1,67 @@
+/* toml_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"testing"
+)
+
+// TestParseTOML will test parseTOML
+func TestParseTOML(t *testing.T) {
+	content := []byte(`
+name = "panel # not a comment" # a comment
+level = 2
+enabled = true
+items = [
+	'a',
+	"b\"c",
+]
+
+[[step]]
+key = 'first'
+
+[[step]]
+key = 'second'
+`)
+
+	table, parseErr := parseTOML(content)
+
+	if parseErr != nil {
+		t.Fatalf("Failed to parse TOML: %s", parseErr)
+	}
+
+	if table["name"] != "panel # not a comment" {
+		t.Errorf("Failed to parse string with a # in it, got %v", table["name"])
+	}
+
+	if table["level"] != int64(2) || table["enabled"] != true {
+		t.Errorf("Failed to parse integer and boolean, got %v and %v", table["level"], table["enabled"])
+	}
+
+	if items := table["items"].([]interface{}); len(items) != 2 || items[1] != "b\"c" {
+		t.Errorf("Failed to parse multi-line array, got %v", items)
+	}
+
+	if steps := table["step"].([]tomlTable); len(steps) != 2 || steps[1]["key"] != "second" {
+		t.Errorf("Failed to parse array of tables, got %v", table["step"])
+	}
+
+	if _, parseErr = parseTOML([]byte("name = \"unterminated")); parseErr == nil {
+		t.Error("Parsed an unterminated string without error")
+	}
+}
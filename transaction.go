@@ -0,0 +1,259 @@
+/* transaction.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// journalEntry is a single mutation within a Transaction, stored as the state of the sections it touched before and after
+type journalEntry struct {
+	after       sectionSnapshot
+	before      sectionSnapshot
+	description string
+}
+
+// Begin will start a new Transaction on our Schema
+// The Schema is not modified until the Transaction is committed, and must not be changed directly while the Transaction is open
+func (schema *Schema) Begin() *Transaction {
+	return &Transaction{
+		began:   schema.Fingerprint(),
+		journal: []journalEntry{},
+		redo:    []journalEntry{},
+		schema:  schema,
+		working: schema.Clone(),
+	}
+}
+
+// Schema will return the Transaction's working copy of the Schema, with all mutations so far applied
+// This should only be read from, mutations should go through the Transaction so they are journaled
+func (tx *Transaction) Schema() *Schema {
+	return tx.working
+}
+
+// Journal will return a description of each mutation in the Transaction that has not been undone, oldest first
+func (tx *Transaction) Journal() []string {
+	descriptions := []string{}
+
+	for _, entry := range tx.journal {
+		descriptions = append(descriptions, entry.description)
+	}
+
+	return descriptions
+}
+
+// Commit will apply all mutations in the Transaction to the Schema and close the Transaction
+// If the Schema was changed since Begin, ErrTransactionConflict is returned and the Transaction is left open, so those changes
+// are not overwritten. It can then be rolled back
+func (tx *Transaction) Commit() error {
+	if tx.closed {
+		return ErrTransactionClosed
+	}
+
+	if tx.schema.Fingerprint() != tx.began { // Changed outside of the Transaction
+		return ErrTransactionConflict
+	}
+
+	*tx.schema = *tx.working // Swap in our working copy
+	tx.closed = true
+	return nil
+}
+
+// Rollback will discard all mutations in the Transaction and close it, leaving the Schema untouched
+func (tx *Transaction) Rollback() error {
+	if tx.closed {
+		return ErrTransactionClosed
+	}
+
+	tx.closed = true
+	return nil
+}
+
+// Undo will undo the most recent mutation
+func (tx *Transaction) Undo() error {
+	if tx.closed {
+		return ErrTransactionClosed
+	}
+
+	if len(tx.journal) == 0 {
+		return ErrNothingToUndo
+	}
+
+	entry := tx.journal[len(tx.journal)-1]
+	entry.before.restore(tx.working)
+
+	tx.journal = tx.journal[:len(tx.journal)-1]
+	tx.redo = append(tx.redo, entry)
+	return nil
+}
+
+// Redo will redo the most recently undone mutation
+// Any new mutation after an Undo clears what can be redone
+func (tx *Transaction) Redo() error {
+	if tx.closed {
+		return ErrTransactionClosed
+	}
+
+	if len(tx.redo) == 0 {
+		return ErrNothingToRedo
+	}
+
+	entry := tx.redo[len(tx.redo)-1]
+	entry.after.restore(tx.working)
+
+	tx.redo = tx.redo[:len(tx.redo)-1]
+	tx.journal = append(tx.journal, entry)
+	return nil
+}
+
+// AddSection will add the section within the Transaction, see Schema's AddSection
+func (tx *Transaction) AddSection(section string, kv *SchemaKV) error {
+	return tx.record("add section "+section, []string{section}, func() error {
+		return tx.working.AddSection(section, kv.Duplicate()) // Copy so the caller changing kv doesn't bypass our journal
+	})
+}
+
+// DeleteSections will delete the sections within the Transaction, see Schema's DeleteSections
+func (tx *Transaction) DeleteSections(sections ...string) error {
+	step := MigrationStep{Action: MigrationActionDeleteSections, Sections: sections}
+
+	return tx.record("delete sections "+strings.Join(sections, ", "), tx.working.migrationStepTargets(step), func() error {
+		tx.working.DeleteSections(sections...)
+		return nil
+	})
+}
+
+// DeleteSectionsWithPrefix will delete the sections by prefix within the Transaction, see Schema's DeleteSectionsWithPrefix
+func (tx *Transaction) DeleteSectionsWithPrefix(sections ...string) error {
+	step := MigrationStep{Action: MigrationActionDeleteSections, Sections: sections, Prefix: true}
+
+	return tx.record("delete sections with prefix "+strings.Join(sections, ", "), tx.working.migrationStepTargets(step), func() error {
+		tx.working.DeleteSectionsWithPrefix(sections...)
+		return nil
+	})
+}
+
+// MigrateSectionsWithName will migrate the sections within the Transaction, see Schema's MigrateSectionsWithName
+func (tx *Transaction) MigrateSectionsWithName(source string, dest string, exact bool) error {
+	step := MigrationStep{Action: MigrationActionMigrateSections, Source: source, Dest: dest, Exact: exact}
+
+	return tx.record(fmt.Sprintf("migrate sections %s to %s", source, dest), tx.working.migrationStepTargets(step), func() error {
+		return tx.working.MigrateSectionsWithName(source, dest, exact)
+	})
+}
+
+// AddKey will add the key to the section within the Transaction, see SchemaKV's AddKey
+func (tx *Transaction) AddKey(section string, key string, t *SchemaType) error {
+	return tx.recordKV(fmt.Sprintf("add key %s to %s", key, section), section, func(kv *SchemaKV) error {
+		return kv.AddKey(key, t.Duplicate())
+	})
+}
+
+// DeleteKeys will delete the keys from the section within the Transaction, see SchemaKV's DeleteKeys
+func (tx *Transaction) DeleteKeys(section string, keys ...string) error {
+	return tx.recordKV(fmt.Sprintf("delete keys %s from %s", strings.Join(keys, ", "), section), section, func(kv *SchemaKV) error {
+		kv.DeleteKeys(keys...)
+		return nil
+	})
+}
+
+// ModifyKey will modify the key in the section within the Transaction, see SchemaKV's ModifyKey
+func (tx *Transaction) ModifyKey(section string, key string, mod Modification) error {
+	return tx.recordKV(fmt.Sprintf("modify key %s in %s", key, section), section, func(kv *SchemaKV) error {
+		return kv.ModifyKey(key, mod)
+	})
+}
+
+// MoveKey will move the key in the section within the Transaction, see SchemaKV's MoveKey
+func (tx *Transaction) MoveKey(section string, source string, dest string) error {
+	return tx.recordKV(fmt.Sprintf("move key %s to %s in %s", source, dest, section), section, func(kv *SchemaKV) error {
+		return kv.MoveKey(source, dest)
+	})
+}
+
+// recordKV will record a mutation of a single existing section
+func (tx *Transaction) recordKV(description string, section string, mutate func(kv *SchemaKV) error) error {
+	return tx.record(description, []string{section}, func() error {
+		kv, getErr := tx.working.GetSection(section)
+
+		if getErr != nil {
+			return getErr
+		}
+
+		return mutate(kv)
+	})
+}
+
+// record will apply the mutation to our working copy and journal it
+// If the mutation fails, the sections it touched are restored and nothing is journaled
+func (tx *Transaction) record(description string, sections []string, mutate func() error) error {
+	if tx.closed {
+		return ErrTransactionClosed
+	}
+
+	before := tx.working.snapshotSections(sections...)
+
+	if mutateErr := mutate(); mutateErr != nil {
+		before.restore(tx.working)
+		return mutateErr
+	}
+
+	tx.journal = append(tx.journal, journalEntry{
+		after:       tx.working.snapshotSections(sections...),
+		before:      before,
+		description: description,
+	})
+
+	tx.redo = []journalEntry{} // A new mutation means we can't redo anymore
+	return nil
+}
+
+// restore will return the sections in the snapshot to their snapshotted state in the provided Schema
+// Sections which existed are put back where they were in our Order, so String and lossless output are unchanged
+func (snapshot sectionSnapshot) restore(schema *Schema) {
+	restored := []string{}
+	schema.tree = nil // Sections may be added and deleted
+
+	for section, kv := range snapshot.kvs {
+		schema.Order = RemoveFromStringArr(schema.Order, section) // Put back below, at its snapshotted index
+
+		if kv == nil { // Did not exist
+			delete(schema.Map, section)
+			continue
+		}
+
+		schema.Map[section] = kv.Duplicate() // Copy so later mutations don't change our snapshot
+		restored = append(restored, section)
+	}
+
+	sort.Slice(restored, func(i, j int) bool { // Insert in the order they were, so each index is as it was
+		return snapshot.order[restored[i]] < snapshot.order[restored[j]]
+	})
+
+	for _, section := range restored {
+		index := snapshot.order[section]
+
+		if index > len(schema.Order) {
+			index = len(schema.Order)
+		}
+
+		schema.Order = append(schema.Order[:index], append([]string{section}, schema.Order[index:]...)...)
+	}
+}
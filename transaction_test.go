@@ -0,0 +1,157 @@
+/* transaction_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var TestTransactionSection = "applets/{8bc94562-0dae-11eb-ad1d-e0d55e200f1c}"
+
+// TestTransactionCommit will test that a Transaction only changes the Schema on Commit
+func TestTransactionCommit(t *testing.T) {
+	schema := NewTestSchema(t)
+	tx := schema.Begin()
+
+	if modErr := tx.ModifyKey(TestTransactionSection, "alignment", Modification{Value: "'start'"}); modErr != nil {
+		t.Fatalf("Failed to modify alignment: %s", modErr)
+	}
+
+	if delErr := tx.DeleteSectionsWithPrefix("instance"); delErr != nil {
+		t.Fatalf("Failed to delete instance sections: %s", delErr)
+	}
+
+	if !schema.HasSection("instance/tray/{feece066-1f25-11eb-a797-e0d55e200f1c}") { // Should not be touched yet
+		t.Error("Transaction modified the Schema before committing")
+	}
+
+	if commitErr := tx.Commit(); commitErr != nil {
+		t.Fatalf("Failed to commit: %s", commitErr)
+	}
+
+	if schema.HasSection("instance/tray/{feece066-1f25-11eb-a797-e0d55e200f1c}") {
+		t.Error("Commit did not apply our deletion")
+	}
+
+	if commitErr := tx.Commit(); !errors.Is(commitErr, ErrTransactionClosed) {
+		t.Errorf("Expected ErrTransactionClosed, got %v instead.", commitErr)
+	}
+}
+
+// TestTransactionRollback will test that rolling back leaves the Schema untouched
+func TestTransactionRollback(t *testing.T) {
+	schema := NewTestSchema(t)
+	tx := schema.Begin()
+
+	tx.DeleteSections(TestTransactionSection)
+	tx.Rollback()
+
+	if !schema.HasSection(TestTransactionSection) {
+		t.Error("Rolled back Transaction deleted a section")
+	}
+
+	if delErr := tx.DeleteSections(TestTransactionSection); !errors.Is(delErr, ErrTransactionClosed) {
+		t.Errorf("Expected ErrTransactionClosed, got %v instead.", delErr)
+	}
+}
+
+// TestTransactionConflict will test that committing fails rather than overwrite changes made to the Schema directly
+func TestTransactionConflict(t *testing.T) {
+	schema := NewTestSchema(t)
+	tx := schema.Begin()
+
+	tx.DeleteSections(TestTransactionSection)
+	schema.Set("dark-theme", &SchemaType{Type: "bool", Val: "false"}) // Outside of the Transaction
+
+	if commitErr := tx.Commit(); !errors.Is(commitErr, ErrTransactionConflict) {
+		t.Errorf("Expected ErrTransactionConflict, got %v instead.", commitErr)
+	}
+
+	if value, _ := schema.Get("dark-theme"); value.BoolVal || !schema.HasSection(TestTransactionSection) {
+		t.Error("Expected the Schema to keep its own change and not the Transaction's")
+	}
+
+	if rollbackErr := tx.Rollback(); rollbackErr != nil { // Still open
+		t.Errorf("Failed to roll back: %s", rollbackErr)
+	}
+}
+
+// TestTransactionUndoOrder will test that undoing puts sections back where they were, so lossless output is unchanged
+func TestTransactionUndoOrder(t *testing.T) {
+	content := "[zeta]\na=1\n\n[alpha]\nb=2\n\n[mid]\nc=3\n"
+	schema, _ := NewSchemaWithOptions("/org/example/", []byte(content), ParseOptions{Lossless: true})
+	tx := schema.Begin()
+
+	tx.DeleteSections("alpha", "zeta")
+	tx.MigrateSectionsWithName("mid", "moved", true)
+	tx.Undo()
+	tx.Undo()
+
+	if order := strings.Join(tx.Schema().Order, " "); order != "zeta alpha mid" {
+		t.Errorf("Expected the sections to be back in order, got %s", order)
+	}
+
+	if output := tx.Schema().String(); output != content {
+		t.Errorf("Expected:\n%s\nGot:\n%s", content, output)
+	}
+}
+
+// TestTransactionUndoRedo will test Undo and Redo
+func TestTransactionUndoRedo(t *testing.T) {
+	schema := NewTestSchema(t)
+	tx := schema.Begin()
+
+	tx.ModifyKey(TestTransactionSection, "name", Modification{ReplaceValues: []string{"re:Clock", "Calendar"}})
+	tx.MoveKey(TestTransactionSection, "position", "index")
+
+	if moveErr := tx.MoveKey("does/not/exist", "a", "b"); moveErr == nil { // Failed mutations are not journaled
+		t.Error("Moved a key in a section which does not exist")
+	}
+
+	if journal := tx.Journal(); len(journal) != 2 {
+		t.Fatalf("Expected 2 journal entries, got %v instead.", journal)
+	}
+
+	tx.Undo()
+	tx.Undo()
+
+	kv, _ := tx.Schema().GetSection(TestTransactionSection)
+
+	if val, _ := kv.GetVal("name"); val.Val != "'Clock'" || !kv.HasKey("position") {
+		t.Errorf("Undo did not restore the section, got %v", kv.Keys)
+	}
+
+	if undoErr := tx.Undo(); !errors.Is(undoErr, ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo, got %v instead.", undoErr)
+	}
+
+	tx.Redo()
+	kv, _ = tx.Schema().GetSection(TestTransactionSection)
+
+	if val, _ := kv.GetVal("name"); val.Val != "'Calendar'" || !kv.HasKey("position") {
+		t.Errorf("Redo did not reapply only the rename, got %v", kv.Keys)
+	}
+
+	tx.AddKey(TestTransactionSection, "show-seconds", &SchemaType{Type: "bool", BoolVal: true})
+
+	if redoErr := tx.Redo(); !errors.Is(redoErr, ErrNothingToRedo) { // New mutation clears the redo
+		t.Errorf("Expected ErrNothingToRedo, got %v instead.", redoErr)
+	}
+}
@@ -0,0 +1,210 @@
+/* tree.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+// Like dconf, we treat our sections as a tree of directories relative to our Path
+// A directory like applets/ exists if any section is in or under it, even if there is no applets section itself
+// We keep a sorted index of our sections, built in O(n log n) for n sections, after which each directory or prefix is found in O(log n)
+// AddSection and DeleteSections discard it. Map can also be changed directly, so it is rebuilt should the number of sections differ from it
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WalkFunc is called by Walk for each section, return ErrSkipDir to skip the sections under it or any other error to stop walking
+type WalkFunc func(section string, kv *SchemaKV) error
+
+// sectionIndex is a sorted index of our sections as directories, each with a trailing slash and the root section as ""
+// Since every directory ends with a slash, the sections in and under a directory are always adjacent
+type sectionIndex struct {
+	dirs     []string
+	sections map[string]string // Section for each directory
+}
+
+// Children will return the names of the directories directly under the provided directory, each with a trailing slash, like dconf list
+// Directories starting with / are dconf paths, anything else is relative to our Path
+func (schema *Schema) Children(dir string) (children []string, treeErr error) {
+	children = []string{}
+
+	if dir, treeErr = schema.resolveDir(dir); treeErr != nil {
+		return
+	}
+
+	for _, sectionDir := range schema.index().under(dir) {
+		rest := strings.TrimPrefix(sectionDir, dir)
+
+		if rest == "" { // The directory itself
+			continue
+		}
+
+		child := rest[:strings.Index(rest, "/")+1]
+
+		if len(children) == 0 || children[len(children)-1] != child { // Index is sorted, so duplicates are adjacent
+			children = append(children, child)
+		}
+	}
+
+	return
+}
+
+// Keys will return the sorted names of the keys directly in the provided directory
+// Directories starting with / are dconf paths, anything else is relative to our Path
+func (schema *Schema) Keys(dir string) (keys []string, treeErr error) {
+	keys = []string{}
+
+	if dir, treeErr = schema.resolveDir(dir); treeErr != nil {
+		return
+	}
+
+	if kv, exists := schema.Map[dirSection(dir)]; exists {
+		keys = kv.sortedKeys()
+	}
+
+	return
+}
+
+// Walk will call the WalkFunc for the section of the provided directory and every section under it, in sorted order
+// Directories without keys of their own have no section, so are not visited
+// Directories starting with / are dconf paths, anything else is relative to our Path
+func (schema *Schema) Walk(dir string, walkFn WalkFunc) (walkErr error) {
+	if dir, walkErr = schema.resolveDir(dir); walkErr != nil {
+		return
+	}
+
+	index := schema.index()
+	skipping := "" // Directory we are skipping the sections under
+
+	for _, sectionDir := range index.under(dir) {
+		if skipping != "" && strings.HasPrefix(sectionDir, skipping) {
+			continue
+		}
+
+		section := index.sections[sectionDir]
+
+		if walkErr = walkFn(section, schema.Map[section]); walkErr == ErrSkipDir {
+			walkErr = nil
+			skipping = sectionDir
+
+			if sectionDir == "" { // Skipping the root skips everything
+				return
+			}
+		} else if walkErr != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// sectionsWithPrefix will return the sorted sections in or under each of the provided directories, respecting directory boundaries
+// So applets matches applets and applets/clock, but not applets-old. An empty prefix or / matches everything
+func (schema *Schema) sectionsWithPrefix(prefixes ...string) (sections []string) {
+	index := schema.index()
+	seen := make(map[string]bool)
+
+	for _, prefix := range prefixes {
+		dir := TrimSectionSlashes(prefix)
+
+		if dir != "" {
+			dir += "/"
+		}
+
+		for _, sectionDir := range index.under(dir) {
+			if section := index.sections[sectionDir]; !seen[section] {
+				seen[section] = true
+				sections = append(sections, section)
+			}
+		}
+	}
+
+	sort.Strings(sections)
+	return
+}
+
+// index will return the sectionIndex of our sections, building it if we have none or it no longer matches our Map
+func (schema *Schema) index() *sectionIndex {
+	if schema.tree != nil && len(schema.tree.dirs) == len(schema.Map) {
+		return schema.tree
+	}
+
+	index := &sectionIndex{
+		dirs:     make([]string, 0, len(schema.Map)),
+		sections: make(map[string]string, len(schema.Map)),
+	}
+
+	for section := range schema.Map {
+		dir := ""
+
+		if section != "/" { // Root section is the root directory
+			dir = TrimSectionSlashes(section) + "/"
+		}
+
+		index.dirs = append(index.dirs, dir)
+		index.sections[dir] = section
+	}
+
+	sort.Strings(index.dirs)
+	schema.tree = index
+	return index
+}
+
+// under will return the sorted directories of the sections in or under the provided directory
+func (index *sectionIndex) under(dir string) []string {
+	start := sort.SearchStrings(index.dirs, dir)
+	end := start
+
+	for end < len(index.dirs) && strings.HasPrefix(index.dirs[end], dir) {
+		end++
+	}
+
+	return index.dirs[start:end]
+}
+
+// resolveDir will resolve the directory to one relative to our Path, with a trailing slash or "" for our Path itself
+func (schema *Schema) resolveDir(dir string) (string, error) {
+	base := schema.basePath()
+
+	if strings.HasPrefix(dir, "/") { // dconf path
+		if dir = strings.TrimSuffix(dir, "/") + "/"; !strings.HasPrefix(dir, base) {
+			return "", fmt.Errorf("%w: %q is not under %q", ErrKeyPathOutsideSchema, dir, base)
+		}
+
+		dir = strings.TrimPrefix(dir, base)
+	}
+
+	if strings.Contains(dir, "//") || dir == "/" { // Empty directory name
+		return "", fmt.Errorf("%w: %q", ErrInvalidKeyPath, dir)
+	}
+
+	if dir = strings.TrimSuffix(dir, "/"); dir != "" {
+		dir += "/"
+	}
+
+	return dir, nil
+}
+
+// dirSection will return the section for the directory resolved by resolveDir
+func dirSection(dir string) string {
+	if dir == "" {
+		return "/"
+	}
+
+	return strings.TrimSuffix(dir, "/")
+}
@@ -0,0 +1,172 @@
+/* tree_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// NewTreeSchema will return a Schema with nested sections, including one sharing a prefix but not a directory with another
+func NewTreeSchema(t *testing.T) *Schema {
+	schema, parseErr := NewSchema("/com/example/", []byte(`[/]
+root=true
+
+[applets/clock]
+name='Clock'
+position=1
+
+[applets/menu]
+name='Menu'
+
+[applets/menu/extra]
+icon='start'
+
+[applets-old]
+name='Old'
+
+[panels/main/layout]
+size=40
+`))
+
+	if parseErr != nil {
+		t.Fatalf("Failed to parse tree schema: %s", parseErr)
+	}
+
+	return schema
+}
+
+// TestChildren will test Children
+func TestChildren(t *testing.T) {
+	schema := NewTreeSchema(t)
+
+	tests := map[string]string{
+		"":                      "applets-old/,applets/,panels/",
+		"/com/example/":         "applets-old/,applets/,panels/",
+		"applets":               "clock/,menu/",
+		"/com/example/applets/": "clock/,menu/",
+		"panels/":               "main/", // Directory without a section of its own
+		"applets/clock":         "",
+		"missing":               "",
+	}
+
+	for dir, expected := range tests {
+		children, childErr := schema.Children(dir)
+
+		if childErr != nil || strings.Join(children, ",") != expected {
+			t.Errorf("Expected children of %q to be %q, got %v (%v) instead.", dir, expected, children, childErr)
+		}
+	}
+
+	if _, childErr := schema.Children("/org/gnome/"); !errors.Is(childErr, ErrKeyPathOutsideSchema) {
+		t.Errorf("Expected ErrKeyPathOutsideSchema, got %v instead.", childErr)
+	}
+}
+
+// TestKeys will test Keys
+func TestKeys(t *testing.T) {
+	schema := NewTreeSchema(t)
+
+	if keys, _ := schema.Keys("applets/clock/"); strings.Join(keys, ",") != "name,position" {
+		t.Errorf("Unexpected keys of applets/clock: %v", keys)
+	}
+
+	if keys, _ := schema.Keys(""); strings.Join(keys, ",") != "root" {
+		t.Errorf("Unexpected keys of the root: %v", keys)
+	}
+
+	if keys, _ := schema.Keys("applets"); len(keys) != 0 {
+		t.Errorf("Expected applets to have no keys, got %v instead.", keys)
+	}
+}
+
+// TestWalk will test Walk, including skipping directories
+func TestWalk(t *testing.T) {
+	schema := NewTreeSchema(t)
+	visited := []string{}
+
+	walkErr := schema.Walk("applets", func(section string, kv *SchemaKV) error {
+		visited = append(visited, section)
+		return nil
+	})
+
+	if walkErr != nil || strings.Join(visited, ",") != "applets/clock,applets/menu,applets/menu/extra" {
+		t.Errorf("Unexpected walk of applets: %v (%v)", visited, walkErr)
+	}
+
+	visited = []string{}
+
+	schema.Walk("", func(section string, kv *SchemaKV) error {
+		visited = append(visited, section)
+
+		if section == "applets/menu" {
+			return ErrSkipDir
+		}
+
+		return nil
+	})
+
+	if strings.Join(visited, ",") != "/,applets-old,applets/clock,applets/menu,panels/main/layout" {
+		t.Errorf("Unexpected walk skipping applets/menu: %v", visited)
+	}
+
+	stop := errors.New("stop")
+
+	if walkErr = schema.Walk("", func(string, *SchemaKV) error { return stop }); walkErr != stop {
+		t.Errorf("Expected Walk to return our error, got %v instead.", walkErr)
+	}
+}
+
+// TestDeleteSectionsWithPrefixBoundary will test that DeleteSectionsWithPrefix respects directory boundaries
+func TestDeleteSectionsWithPrefixBoundary(t *testing.T) {
+	schema := NewTreeSchema(t)
+	schema.DeleteSectionsWithPrefix("applets")
+
+	if strings.Join(schema.sortedSections(), ",") != "/,applets-old,panels/main/layout" {
+		t.Errorf("Unexpected sections after deleting applets: %v", schema.sortedSections())
+	}
+}
+
+// TestTreeIndexUpdates will test that the index kept between calls follows sections added and deleted, including directly in Map
+func TestTreeIndexUpdates(t *testing.T) {
+	schema := NewTreeSchema(t)
+	schema.Children("applets")
+
+	if first := schema.index(); first != schema.index() {
+		t.Error("Expected the index to be kept between calls")
+	}
+
+	tests := []struct {
+		change   func()
+		expected string
+	}{
+		{func() { schema.AddSection("applets/tray", &SchemaKV{Keys: map[string]*SchemaType{}}) }, "clock/,menu/,tray/"},
+		{func() { schema.DeleteSections("applets/clock") }, "menu/,tray/"},
+		{func() { schema.Map["applets/notes"] = &SchemaKV{Keys: map[string]*SchemaType{}} }, "menu/,notes/,tray/"},
+		{func() { delete(schema.Map, "applets/tray") }, "menu/,notes/"},
+	}
+
+	for index, test := range tests {
+		test.change()
+
+		if children, _ := schema.Children("applets"); strings.Join(children, ",") != test.expected {
+			t.Errorf("Expected children %q after change %d, got %v instead.", test.expected, index, children)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/* validate.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ValidationInvalidChoice means a key's value is not one of its choices
+	ValidationInvalidChoice = "invalid-choice"
+
+	// ValidationInvalidEnum means a key's value is not a nick of its enum or flags
+	ValidationInvalidEnum = "invalid-enum"
+
+	// ValidationInvalidRange means a key's range in its GSettings schema has a bound that is not a number, so its value could not be fully checked
+	ValidationInvalidRange = "invalid-range"
+
+	// ValidationOutOfRange means a key's value is outside of its range
+	ValidationOutOfRange = "out-of-range"
+
+	// ValidationUnknownKey means a key is not in the GSettings schema of its section
+	ValidationUnknownKey = "unknown-key"
+
+	// ValidationUnknownSchema means a section has no GSettings schema, like one left behind by an uninstalled application
+	ValidationUnknownSchema = "unknown-schema"
+
+	// ValidationUnownedRelocatable means a section has no GSettings schema but is under the path of one, like the settings of
+	// an applet whose relocatable schema is no longer installed or mounted. Only FindStale tells these apart from ValidationUnknownSchema
+	ValidationUnownedRelocatable = "unowned-relocatable"
+
+	// ValidationWrongType means a key's value is not of its type
+	ValidationWrongType = "wrong-type"
+)
+
+// Validate will check every section and key of the Schema against the GSettings schemas of the source
+// Each section is mapped to a schema by its full path with a SchemaMapper. Keys are then checked for existence, type, range, choices and enum or flags nicks
+func Validate(schema *Schema, source SchemaSource) *ValidationReport {
+	report := &ValidationReport{
+		Issues:   []ValidationIssue{},
+		Sections: make(map[string]string),
+	}
+
+	mapper := NewSchemaMapper(source)
+
+	for _, section := range schema.sortedSections() {
+		dir := schema.KeyPath(section, "")
+		id := mapper.SchemaID(dir)
+		gschema := source.Schema(id)
+
+		if gschema == nil {
+			report.Issues = append(report.Issues, ValidationIssue{
+				Kind:    ValidationUnknownSchema,
+				Message: fmt.Sprintf("no schema is at %s", dir),
+				Path:    dir,
+				Section: section,
+			})
+
+			continue
+		}
+
+		report.Sections[section] = id
+		kv := schema.Map[section]
+
+		for _, key := range kv.sortedKeys() {
+			kind, message := validateKey(source, gschema.Keys[key], kv.Keys[key])
+
+			if kind == "" { // Valid
+				continue
+			}
+
+			report.Issues = append(report.Issues, ValidationIssue{
+				Key:     key,
+				Kind:    kind,
+				Message: message,
+				Path:    dir + key,
+				Schema:  id,
+				Section: section,
+			})
+		}
+	}
+
+	return report
+}
+
+// Valid will check if the report has no issues
+func (report *ValidationReport) Valid() bool {
+	return len(report.Issues) == 0
+}
+
+// IssuesOfKind will return the issues of the kind, one of the Validation constants
+func (report *ValidationReport) IssuesOfKind(kind string) (issues []ValidationIssue) {
+	for _, issue := range report.Issues {
+		if issue.Kind == kind {
+			issues = append(issues, issue)
+		}
+	}
+
+	return
+}
+
+// validateKey will check the value against the GSettings key, returning the kind of problem and a message if there is one
+func validateKey(source SchemaSource, key *GSchemaKey, t *SchemaType) (kind string, message string) {
+	if key == nil {
+		return ValidationUnknownKey, "key is not in the schema"
+	}
+
+	text := t.rawValue() // The text as parsed, since formatting may change how GVariant reads it
+	typ, typeErr := parseGVariantType(key.Type)
+	value, parseErr := parseGVariantText(text)
+
+	if typeErr != nil || parseErr != nil || !value.matches(typ) {
+		return ValidationWrongType, fmt.Sprintf("%s is not of type %s", text, key.Type)
+	}
+
+	if key.Range != nil {
+		aboveMin, minOK := compareGVariantNumbers(text, key.Range.Min)
+		belowMax, maxOK := compareGVariantNumbers(text, key.Range.Max)
+
+		switch {
+		case (minOK && aboveMin < 0) || (maxOK && belowMax > 0): // A bound that does not parse still leaves the other to check against
+			return ValidationOutOfRange, fmt.Sprintf("%s is outside of %s to %s", text, key.Range.Min, key.Range.Max)
+		case !minOK || !maxOK:
+			return ValidationInvalidRange, fmt.Sprintf("range %s to %s is not numeric, so %s could not be checked against it", key.Range.Min, key.Range.Max, text)
+		}
+	}
+
+	if len(key.Choices) != 0 && value.kind == 's' && !gschemaStringAccepted(value.text, key.Choices, key.Aliases) {
+		return ValidationInvalidChoice, fmt.Sprintf("%s is not one of %s", text, strings.Join(key.Choices, ", "))
+	}
+
+	enum := source.Enum(key.Enum + key.Flags) // Only one is set
+
+	if enum == nil {
+		return
+	}
+
+	nicks := make([]string, 0, len(enum.Values))
+
+	for _, enumValue := range enum.Values {
+		nicks = append(nicks, enumValue.Nick)
+	}
+
+	values := []*gvariantTextValue{value}
+
+	if key.Flags != "" {
+		values = value.items
+	}
+
+	for _, item := range values {
+		if !gschemaStringAccepted(item.text, nicks, key.Aliases) {
+			return ValidationInvalidEnum, fmt.Sprintf("%s is not one of %s", QuoteString(item.text), strings.Join(nicks, ", "))
+		}
+	}
+
+	return
+}
+
+// gschemaStringAccepted will check if the string is one of the accepted strings, or an alias of one
+func gschemaStringAccepted(str string, accepted []string, aliases map[string]string) bool {
+	if target, isAlias := aliases[str]; isAlias {
+		str = target
+	}
+
+	for _, acceptedStr := range accepted {
+		if str == acceptedStr {
+			return true
+		}
+	}
+
+	return false
+}
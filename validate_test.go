@@ -0,0 +1,233 @@
+/* validate_test.go
+ *
+ * Copyright 2021 Joshua Strobl
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * 	http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libdconf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// NewBudgieGSchemaSet will load our example schemas, mounting the relocatable ones where Budgie uses them
+func NewBudgieGSchemaSet(t *testing.T) *GSchemaSet {
+	set, loadErr := LoadGSchemaDir("examples/schemas")
+
+	if loadErr != nil {
+		t.Fatalf("Failed to load schemas: %s", loadErr)
+	}
+
+	mounts := map[string]string{
+		"/com/solus-project/budgie-panel/applets/*/":                "com.solus-project.budgie-panel.applet",
+		"/com/solus-project/budgie-panel/instance/budgie-menu/*/":   "com.solus-project.budgie-menu",
+		"/com/solus-project/budgie-panel/instance/icon-tasklist/*/": "com.solus-project.icon-tasklist",
+		"/com/solus-project/budgie-panel/instance/tray/*/":          "com.solus-project.tray",
+		"/com/solus-project/budgie-panel/panels/{*-*-*-*-*}/":       "com.solus-project.budgie-panel.panel",
+	}
+
+	for path, id := range mounts {
+		if mountErr := set.Mount(path, id); mountErr != nil {
+			t.Fatalf("Failed to mount %s at %s: %s", id, path, mountErr)
+		}
+	}
+
+	return set
+}
+
+// TestValidate will test validating our example content, which is valid, and then breaking it
+func TestValidate(t *testing.T) {
+	set := NewBudgieGSchemaSet(t)
+	schema := NewTestSchema(t)
+	report := Validate(schema, set)
+
+	if !report.Valid() {
+		t.Fatalf("Expected our example to be valid, got %+v", report.Issues)
+	}
+
+	expectedSections := map[string]string{
+		"/": "com.solus-project.budgie-panel",
+		"applets/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}":              "com.solus-project.budgie-panel.applet",
+		"instance/budgie-menu/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}": "com.solus-project.budgie-menu",
+		"instance/tray/{feece066-1f25-11eb-a797-e0d55e200f1c}":        "com.solus-project.tray",
+		"panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}":               "com.solus-project.budgie-panel.panel",
+	}
+
+	for section, id := range expectedSections {
+		if report.Sections[section] != id {
+			t.Errorf("Expected %s to map to %s, got %q instead.", section, id, report.Sections[section])
+		}
+	}
+
+	if len(report.Sections) != len(schema.Map) {
+		t.Errorf("Expected all %d sections to be mapped, got %d", len(schema.Map), len(report.Sections))
+	}
+
+	broken := map[string]string{
+		"/com/solus-project/budgie-panel/dark-theme":                                                   "'yes'",
+		"/com/solus-project/budgie-panel/removed-key":                                                  "1",
+		"/com/solus-project/budgie-panel/migration-level":                                              "-1",
+		"/com/solus-project/budgie-panel/applets/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}/alignment":     "'middle'",
+		"/com/solus-project/budgie-panel/applets/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}/position":      "101",
+		"/com/solus-project/budgie-panel/instance/old-applet/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}/a": "true",
+		"/com/solus-project/budgie-panel/panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}/size":           "uint32 39",
+	}
+
+	for path, value := range broken {
+		sT, _ := NewSchemaType(value)
+		schema.Set(path, sT)
+	}
+
+	report = Validate(schema, set)
+
+	expected := []ValidationIssue{
+		{Key: "dark-theme", Kind: ValidationWrongType, Section: "/", Schema: "com.solus-project.budgie-panel"},
+		{Key: "migration-level", Kind: ValidationWrongType, Section: "/", Schema: "com.solus-project.budgie-panel"},
+		{Key: "removed-key", Kind: ValidationUnknownKey, Section: "/", Schema: "com.solus-project.budgie-panel"},
+		{Key: "alignment", Kind: ValidationInvalidEnum, Section: "applets/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}", Schema: "com.solus-project.budgie-panel.applet"},
+		{Key: "position", Kind: ValidationOutOfRange, Section: "applets/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}", Schema: "com.solus-project.budgie-panel.applet"},
+		{Kind: ValidationUnknownSchema, Section: "instance/old-applet/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}"},
+		{Key: "size", Kind: ValidationWrongType, Section: "panels/{8bba3bf8-0dae-11eb-ad1d-e0d55e200f1c}", Schema: "com.solus-project.budgie-panel.panel"},
+	}
+
+	if len(report.Issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %+v", len(expected), report.Issues)
+	}
+
+	for index, issue := range report.Issues {
+		if issue.Key != expected[index].Key || issue.Kind != expected[index].Kind || issue.Section != expected[index].Section || issue.Schema != expected[index].Schema || issue.Message == "" {
+			t.Errorf("Expected issue %+v, got %+v instead.", expected[index], issue)
+		}
+	}
+
+	if unknown := report.IssuesOfKind(ValidationUnknownSchema); len(unknown) != 1 || unknown[0].Path != "/com/solus-project/budgie-panel/instance/old-applet/{8bbab560-0dae-11eb-ad1d-e0d55e200f1c}/" {
+		t.Errorf("Unexpected unknown schemas: %+v", unknown)
+	}
+}
+
+// TestValidateChildrenChoicesAndFlags will test children, choices, aliases and flags
+func TestValidateChildrenChoicesAndFlags(t *testing.T) {
+	set, _ := ParseGSchemaXML(GSchemaXML)
+	schema, _ := NewSchema("/org/example/", []byte(`[/]
+color='crimson'
+features=['sound', 'video']
+mode='on'
+volume=1
+
+[window]
+size=(800, 600)
+`))
+
+	if report := Validate(schema, set); !report.Valid() || report.Sections["window"] != "org.example.window" {
+		t.Fatalf("Expected a valid report, got %+v", report)
+	}
+
+	schema, _ = NewSchema("/org/example/", []byte(`[/]
+color='green'
+features=['sound', 'smell']
+volume=1.5
+
+[window]
+size=(800, 'tall')
+
+[window/extra]
+key=true
+`))
+
+	var kinds []string
+	for _, issue := range Validate(schema, set).Issues {
+		kinds = append(kinds, issue.Key+" "+issue.Kind)
+	}
+
+	expected := []string{"color " + ValidationInvalidChoice, "features " + ValidationInvalidEnum, "volume " + ValidationOutOfRange, "size " + ValidationWrongType, " " + ValidationUnknownSchema}
+
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Expected issues %v, got %v instead.", expected, kinds)
+	}
+}
+
+// TestValidateLargeNumbers will test that numbers of a million or more are checked as written, so an exponent is a double
+func TestValidateLargeNumbers(t *testing.T) {
+	set, _ := ParseGSchemaXML([]byte(`<schemalist>
+  <schema id="org.example.numbers" path="/org/example/numbers/">
+    <key name="count" type="i"><default>0</default></key>
+    <key name="offset" type="x"><default>0</default></key>
+    <key name="ratio" type="d"><default>0.0</default></key>
+    <key name="total" type="u"><default>0</default></key>
+  </schema>
+</schemalist>`))
+
+	schema, _ := NewSchema("/org/example/numbers/", []byte("[/]\ncount=1000000\noffset=int64 9007199254740993\nratio=1000000.0\ntotal=uint32 4000000000\n"))
+
+	if report := Validate(schema, set); !report.Valid() {
+		t.Errorf("Expected a valid report, got %+v", report.Issues)
+	}
+
+	schema, _ = NewSchema("/org/example/numbers/", []byte("[/]\ncount=1E+06\nratio=1000000\n"))
+
+	var kinds []string
+	for _, issue := range Validate(schema, set).Issues {
+		kinds = append(kinds, issue.Key+" "+issue.Kind)
+	}
+
+	if expected := []string{"count " + ValidationWrongType}; !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Expected issues %v, got %v instead.", expected, kinds)
+	}
+}
+
+// TestValidateInvalidRange will test that a range bound which is not a number is reported, while the other bound is still checked
+func TestValidateInvalidRange(t *testing.T) {
+	set, _ := ParseGSchemaXML([]byte(`<schemalist>
+  <schema id="org.example.range" path="/org/example/range/">
+    <key name="size" type="i"><default>0</default><range min="1_0" max="10"/></key>
+  </schema>
+</schemalist>`))
+
+	tests := map[string]string{"size=20": ValidationOutOfRange, "size=5": ValidationInvalidRange}
+
+	for content, expected := range tests {
+		schema, _ := NewSchema("/org/example/range/", []byte("[/]\n"+content+"\n"))
+
+		if issues := Validate(schema, set).Issues; len(issues) != 1 || issues[0].Kind != expected {
+			t.Errorf("Expected a %s issue for %s, got %+v instead.", expected, content, issues)
+		}
+	}
+}
+
+// TestGSchemaSetMount will test that only relocatable schemas can be mounted, and only at full paths
+func TestGSchemaSetMount(t *testing.T) {
+	set, _ := ParseGSchemaXML(GSchemaXML)
+
+	if mountErr := set.Mount("/org/example/windows/*/", "org.example.window"); mountErr != nil {
+		t.Errorf("Failed to mount: %s", mountErr)
+	}
+
+	if mountErr := set.Mount("/org/example/other/", "org.example"); !errors.Is(mountErr, ErrGSchemaInvalid) {
+		t.Errorf("Expected ErrGSchemaInvalid mounting a fixed schema, got %v instead.", mountErr)
+	}
+
+	if mountErr := set.Mount("/org/example/other/", "org.example.missing"); !errors.Is(mountErr, ErrGSchemaNotFound) {
+		t.Errorf("Expected ErrGSchemaNotFound, got %v instead.", mountErr)
+	}
+
+	if mountErr := set.Mount("windows/*", "org.example.window"); !errors.Is(mountErr, ErrInvalidKeyPath) {
+		t.Errorf("Expected ErrInvalidKeyPath, got %v instead.", mountErr)
+	}
+
+	if mounts := set.Mounts(); len(mounts) != 1 || mounts[0].Schema != "org.example.window" {
+		t.Errorf("Unexpected mounts: %+v", mounts)
+	}
+}